@@ -0,0 +1,264 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrDecimal returned when value can't be parsed as decimal.
+var ErrDecimal = errors.New("invalid decimal")
+
+const nanoFactor = 1_000_000_000
+
+var bigNanoFactor = big.NewInt(nanoFactor)
+
+// Decimal is exact decimal number with 9 fractional digits.
+// Value is Units + Nano/1e9, Nano has the same sign as Units.
+type Decimal struct {
+	Units int64
+	Nano  int32
+}
+
+// ParseDecimal parses decimal from string like "123.45" or "1e-5".
+// Fractional digits after 9th are rounded.
+func ParseDecimal(s string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrDecimal, s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(bigNanoFactor))
+
+	// round half away from zero
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+
+	units, nano := new(big.Int).QuoRem(quo, bigNanoFactor, new(big.Int))
+	if !units.IsInt64() {
+		return Decimal{}, fmt.Errorf("%w: %q is out of range", ErrDecimal, s)
+	}
+
+	return Decimal{Units: units.Int64(), Nano: int32(nano.Int64())}, nil
+}
+
+// DecimalFromFloat returns decimal by shortest representation of float.
+func DecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+
+	return d
+}
+
+func newDecimal(units, nano int64) Decimal {
+	units += nano / nanoFactor
+	nano %= nanoFactor
+
+	switch {
+	case units > 0 && nano < 0:
+		units--
+		nano += nanoFactor
+	case units < 0 && nano > 0:
+		units++
+		nano -= nanoFactor
+	}
+
+	return Decimal{Units: units, Nano: int32(nano)}
+}
+
+// Float64 returns nearest float of decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns decimal without trailing zeros, e.g. "-0.5".
+func (d Decimal) String() string {
+	units, nano := d.Units, int64(d.Nano)
+
+	sign := ""
+	if units < 0 || nano < 0 {
+		sign = "-"
+	}
+	if nano < 0 {
+		nano = -nano
+	}
+
+	s := sign + strings.TrimPrefix(strconv.FormatInt(units, 10), "-")
+	if nano == 0 {
+		return s
+	}
+
+	return s + "." + strings.TrimRight(fmt.Sprintf("%09d", nano), "0")
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	return newDecimal(d.Units+other.Units, int64(d.Nano)+int64(other.Nano))
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{Units: -d.Units, Nano: -d.Nano}
+}
+
+// Cmp returns -1, 0 or 1 when d is less, equal or greater than other.
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.Units != other.Units:
+		if d.Units < other.Units {
+			return -1
+		}
+		return 1
+	case d.Nano != other.Nano:
+		if d.Nano < other.Nano {
+			return -1
+		}
+		return 1
+	}
+
+	return 0
+}
+
+// IsZero reports whether d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Units == 0 && d.Nano == 0
+}
+
+// MarshalJSON for implements json.Marshaler, decimal is encoded as JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON for implements json.Unmarshaler, accepts JSON number or string.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+// Decimal returns exact value of money amount as it was sent by api.
+// Value is converted from float when it was changed after decoding.
+func (m MoneyAmount) Decimal() Decimal {
+	return exactOrFloat(m.exact, m.Value)
+}
+
+// PriceDecimal returns exact price of order, see MoneyAmount.Decimal.
+func (o Order) PriceDecimal() Decimal {
+	return exactOrFloat(o.exactPrice, o.Price)
+}
+
+// PriceDecimal returns exact price of operation, see MoneyAmount.Decimal.
+func (o Operation) PriceDecimal() Decimal {
+	return exactOrFloat(o.exactPrice, o.Price)
+}
+
+// MinPriceIncrementDecimal returns exact price step of instrument, see MoneyAmount.Decimal.
+func (i Instrument) MinPriceIncrementDecimal() Decimal {
+	return exactOrFloat(i.exactMinPriceIncrement, i.MinPriceIncrement)
+}
+
+// BalanceDecimal returns exact balance of position, see MoneyAmount.Decimal.
+func (p PositionBalance) BalanceDecimal() Decimal {
+	return exactOrFloat(p.exactBalance, p.Balance)
+}
+
+// BalanceDecimal returns exact balance of currency, see MoneyAmount.Decimal.
+func (b CurrencyBalance) BalanceDecimal() Decimal {
+	return exactOrFloat(b.exactBalance, b.Balance)
+}
+
+// exactOrFloat returns exact decoded value unless float value is changed after decoding.
+func exactOrFloat(exact Decimal, value float64) Decimal {
+	if exact.Float64() == value {
+		return exact
+	}
+
+	return DecimalFromFloat(value)
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (m *MoneyAmount) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Currency Currency `json:"currency"`
+		Value    Decimal  `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*m = MoneyAmount{Currency: v.Currency, Value: v.Value.Float64(), exact: v.Value}
+
+	return nil
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (p *PositionBalance) UnmarshalJSON(data []byte) error {
+	type position PositionBalance
+
+	var v position
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exact struct {
+		Balance Decimal `json:"balance"`
+	}
+	if err := json.Unmarshal(data, &exact); err != nil {
+		return err
+	}
+
+	*p = PositionBalance(v)
+	p.exactBalance = exact.Balance
+
+	return nil
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (b *CurrencyBalance) UnmarshalJSON(data []byte) error {
+	type balance CurrencyBalance
+
+	var v balance
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exact struct {
+		Balance Decimal `json:"balance"`
+	}
+	if err := json.Unmarshal(data, &exact); err != nil {
+		return err
+	}
+
+	*b = CurrencyBalance(v)
+	b.exactBalance = exact.Balance
+
+	return nil
+}
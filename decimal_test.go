@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExactDecimalFields(t *testing.T) {
+	var order Order
+	var op Operation
+	var instrument Instrument
+	var position PositionBalance
+	var currency CurrencyBalance
+
+	for _, fixture := range []struct {
+		data string
+		v    interface{}
+	}{
+		{`{"orderId":"1","price":261.37}`, &order},
+		{`{"id":"1","price":0.000000001}`, &op},
+		{`{"figi":"FIGI","minPriceIncrement":0.0025}`, &instrument},
+		{`{"figi":"FIGI","balance":1234567.123456789}`, &position},
+		{`{"currency":"RUB","balance":1e-9}`, &currency},
+	} {
+		if err := json.Unmarshal([]byte(fixture.data), fixture.v); err != nil {
+			t.Fatalf("unmarshal %s: %v", fixture.data, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"order price", order.PriceDecimal(), "261.37"},
+		{"operation price", op.PriceDecimal(), "0.000000001"},
+		{"min price increment", instrument.MinPriceIncrementDecimal(), "0.0025"},
+		{"position balance", position.BalanceDecimal(), "1234567.123456789"},
+		{"currency balance", currency.BalanceDecimal(), "0.000000001"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// changed float value is used instead of decoded one
+	order.Price = 100.5
+	if got := order.PriceDecimal().String(); got != "100.5" {
+		t.Errorf("changed order price: %s", got)
+	}
+}
@@ -2,10 +2,39 @@ package sdk
 
 import "fmt"
 
+// APIError contains error response of rest api, TradingError converts to it with errors.As.
+type APIError struct {
+	Status     int
+	Code       string
+	Message    string
+	TrackingID string
+}
+
+// Error for implements error.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error: status %d, code %s, message %s, tracking id %s", e.Status, e.Code, e.Message, e.TrackingID)
+}
+
+// NetworkError returned when request failed before response is received.
+type NetworkError struct {
+	Err error
+}
+
+// Error for implements error.
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+// Unwrap returns underlying error.
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
 // TradingError contains error info from tinkoff invest.
 type TradingError struct {
 	TrackingID string `json:"trackingId"`
 	Status     string `json:"status"`
+	HTTPStatus int    `json:"-"`
 	Hint       string
 	Payload    struct {
 		Message string `json:"message"`
@@ -21,6 +50,23 @@ func (t TradingError) Error() string {
 	)
 }
 
+// As for errors.As with *APIError.
+func (t TradingError) As(target interface{}) bool {
+	apiErr, ok := target.(**APIError)
+	if !ok {
+		return false
+	}
+
+	*apiErr = &APIError{
+		Status:     t.HTTPStatus,
+		Code:       t.Payload.Code,
+		Message:    t.Payload.Message,
+		TrackingID: t.TrackingID,
+	}
+
+	return true
+}
+
 // NotEnoughBalance for check error.
 func (t TradingError) NotEnoughBalance() bool {
 	return t.Payload.Code == "NOT_ENOUGH_BALANCE"
@@ -0,0 +1,136 @@
+package sdk
+
+import (
+	"errors"
+	"math"
+)
+
+// Errors of indicators.
+var (
+	ErrNoVolume = errors.New("no volume")
+	ErrPeriod   = errors.New("invalid period. Should be positive")
+)
+
+// VWAP returns volume weighted average of typical price (H+L+C)/3 of candles.
+func VWAP(candles []Candle) (float64, error) {
+	var sum, volume float64
+	for _, c := range candles {
+		typical := (c.HighPrice + c.LowPrice + c.ClosePrice) / 3
+		sum += typical * c.Volume
+		volume += c.Volume
+	}
+
+	if volume == 0 {
+		return 0, ErrNoVolume
+	}
+
+	return sum / volume, nil
+}
+
+// SMA returns simple moving average of close prices.
+// Result has same length as candles, values of warm-up region (first period-1 values) are NaN.
+func SMA(candles []Candle, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, ErrPeriod
+	}
+
+	result := nanSlice(len(candles))
+
+	var sum float64
+	for i := range candles {
+		sum += candles[i].ClosePrice
+		if i >= period {
+			sum -= candles[i-period].ClosePrice
+		}
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+
+	return result, nil
+}
+
+// EMA returns exponential moving average of close prices with smoothing 2/(period+1).
+// First value is SMA of first period candles. Result has same length as candles,
+// values of warm-up region (first period-1 values) are NaN.
+func EMA(candles []Candle, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, ErrPeriod
+	}
+
+	result := nanSlice(len(candles))
+	if len(candles) < period {
+		return result, nil
+	}
+
+	var sum float64
+	for i := 0; i < period; i++ {
+		sum += candles[i].ClosePrice
+	}
+	result[period-1] = sum / float64(period)
+
+	k := 2 / float64(period+1)
+	for i := period; i < len(candles); i++ {
+		result[i] = candles[i].ClosePrice*k + result[i-1]*(1-k)
+	}
+
+	return result, nil
+}
+
+// RSI returns relative strength index of close prices with Wilder's smoothing.
+// Result has same length as candles, values of warm-up region (first period values) are NaN.
+func RSI(candles []Candle, period int) ([]float64, error) {
+	if period < 1 {
+		return nil, ErrPeriod
+	}
+
+	result := nanSlice(len(candles))
+	if len(candles) <= period {
+		return result, nil
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		g, l := priceChange(candles[i-1], candles[i])
+		gain += g
+		loss += l
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	result[period] = rsi(gain, loss)
+
+	for i := period + 1; i < len(candles); i++ {
+		g, l := priceChange(candles[i-1], candles[i])
+		gain = (gain*float64(period-1) + g) / float64(period)
+		loss = (loss*float64(period-1) + l) / float64(period)
+		result[i] = rsi(gain, loss)
+	}
+
+	return result, nil
+}
+
+func priceChange(prev, cur Candle) (gain, loss float64) {
+	change := cur.ClosePrice - prev.ClosePrice
+	if change > 0 {
+		return change, 0
+	}
+
+	return 0, -change
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
+
+func nanSlice(n int) []float64 {
+	result := make([]float64, n)
+	for i := range result {
+		result[i] = math.NaN()
+	}
+
+	return result
+}
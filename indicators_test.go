@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"math"
+	"testing"
+)
+
+func closeCandles(prices ...float64) []Candle {
+	candles := make([]Candle, len(prices))
+	for i, price := range prices {
+		candles[i].ClosePrice = price
+	}
+
+	return candles
+}
+
+// wilderCloses is reference series of RSI(14) from StockCharts.
+var wilderCloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+func TestMovingAverages(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name      string
+		indicator func([]Candle, int) ([]float64, error)
+		closes    []float64
+		period    int
+		want      []float64
+		// tolerance of reference values rounded to two digits
+		tolerance float64
+	}{
+		{name: "SMA", indicator: SMA, closes: []float64{2, 4, 6, 8, 12, 14}, period: 3, want: []float64{nan, nan, 4, 6, 26.0 / 3, 34.0 / 3}},
+		{name: "SMA period 1", indicator: SMA, closes: []float64{2, 4}, period: 1, want: []float64{2, 4}},
+		{name: "SMA short series", indicator: SMA, closes: []float64{2, 4}, period: 3, want: []float64{nan, nan}},
+		{name: "EMA", indicator: EMA, closes: []float64{2, 4, 6, 8, 12, 14}, period: 3, want: []float64{nan, nan, 4, 6, 9, 11.5}},
+		{name: "EMA short series", indicator: EMA, closes: []float64{2, 4}, period: 3, want: []float64{nan, nan}},
+		{name: "RSI monotonic", indicator: RSI, closes: []float64{1, 2, 3, 4}, period: 2, want: []float64{nan, nan, 100, 100}},
+		{name: "RSI short series", indicator: RSI, closes: []float64{1, 2}, period: 2, want: []float64{nan, nan}},
+		{
+			name:      "RSI reference",
+			indicator: RSI,
+			closes:    wilderCloses,
+			period:    14,
+			want: []float64{
+				nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan,
+				70.53, 66.32, 66.55, 69.41, 66.36, 57.97,
+			},
+			tolerance: 0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.indicator(closeCandles(tt.closes...), tt.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d values, want %d", len(got), len(tt.want))
+			}
+
+			tolerance := tt.tolerance
+			if tolerance == 0 {
+				tolerance = 1e-9
+			}
+			for i := range got {
+				if math.IsNaN(tt.want[i]) != math.IsNaN(got[i]) || math.Abs(got[i]-tt.want[i]) > tolerance {
+					t.Fatalf("value %d is %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	for _, indicator := range []func([]Candle, int) ([]float64, error){SMA, EMA, RSI} {
+		if _, err := indicator(closeCandles(1, 2), 0); err != ErrPeriod {
+			t.Fatalf("zero period: %v", err)
+		}
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []Candle
+		want    float64
+		err     error
+	}{
+		{
+			name:    "single candle",
+			candles: []Candle{{HighPrice: 12, LowPrice: 9, ClosePrice: 9, Volume: 5}},
+			want:    10,
+		},
+		{
+			name: "weighted by volume",
+			candles: []Candle{
+				{HighPrice: 10, LowPrice: 10, ClosePrice: 10, Volume: 1},
+				{HighPrice: 22, LowPrice: 18, ClosePrice: 20, Volume: 3},
+			},
+			want: 17.5,
+		},
+		{
+			name: "candle without volume",
+			candles: []Candle{
+				{HighPrice: 10, LowPrice: 10, ClosePrice: 10, Volume: 2},
+				{HighPrice: 100, LowPrice: 100, ClosePrice: 100},
+			},
+			want: 10,
+		},
+		{name: "no candles", err: ErrNoVolume},
+		{name: "no volume", candles: []Candle{{HighPrice: 10, LowPrice: 10, ClosePrice: 10}}, err: ErrNoVolume},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VWAP(tt.candles)
+			if err != tt.err || math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("VWAP %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
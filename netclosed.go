@@ -0,0 +1,14 @@
+//go:build go1.16
+// +build go1.16
+
+package sdk
+
+import (
+	"errors"
+	"net"
+)
+
+// isNetClosed reports whether err is returned by operation on closed network connection.
+func isNetClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
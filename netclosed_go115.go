@@ -0,0 +1,17 @@
+//go:build !go1.16
+// +build !go1.16
+
+package sdk
+
+import (
+	"errors"
+	"net"
+)
+
+// isNetClosed reports whether err is returned by operation on closed network connection.
+// net.ErrClosed is added in go 1.16, before it error of *net.OpError can be matched by text only.
+func isNetClosed(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Err != nil && opErr.Err.Error() == "use of closed network connection"
+}
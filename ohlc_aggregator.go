@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// OHLCAggregator aggregates candle events into bars of larger interval by figi,
+// e.g. 1 minute candles into hour bars. It is safe for concurrent use.
+type OHLCAggregator struct {
+	interval CandleInterval
+	period   time.Duration
+
+	mu   sync.Mutex
+	bars map[string]*aggregatedBar
+}
+
+type aggregatedBar struct {
+	start time.Time
+	// candles by start time, duplicate events of same candle replace previous values
+	candles map[time.Time]Candle
+}
+
+// NewOHLCAggregator returns aggregator into bars of interval. Interval should have fixed duration.
+// Bars start at times truncated to interval duration in UTC.
+func NewOHLCAggregator(interval CandleInterval) (*OHLCAggregator, error) {
+	period := interval.Duration()
+	if period == 0 {
+		return nil, ErrInterval
+	}
+
+	return &OHLCAggregator{
+		interval: interval,
+		period:   period,
+		bars:     make(map[string]*aggregatedBar),
+	}, nil
+}
+
+// Add adds candle of event. When candle starts next bar, previous bar is completed and returned.
+// Candles of already completed bars are ignored.
+func (a *OHLCAggregator) Add(event CandleEvent) (bar Candle, completed bool) {
+	candle := event.Candle
+	start := candle.TS.Truncate(a.period)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current, ok := a.bars[candle.FIGI]
+	if ok && start.Before(current.start) {
+		return Candle{}, false
+	}
+
+	if ok && start.After(current.start) {
+		bar, completed = a.build(candle.FIGI, current), true
+		ok = false
+	}
+
+	if !ok {
+		current = &aggregatedBar{start: start, candles: make(map[time.Time]Candle)}
+		a.bars[candle.FIGI] = current
+	}
+	current.candles[candle.TS] = candle
+
+	return bar, completed
+}
+
+// Current returns not completed bar of figi.
+func (a *OHLCAggregator) Current(figi string) (Candle, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current, ok := a.bars[figi]
+	if !ok {
+		return Candle{}, false
+	}
+
+	return a.build(figi, current), true
+}
+
+func (a *OHLCAggregator) build(figi string, bar *aggregatedBar) Candle {
+	candles := make([]Candle, 0, len(bar.candles))
+	for _, c := range bar.candles {
+		candles = append(candles, c)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].TS.Before(candles[j].TS)
+	})
+
+	result := Candle{
+		FIGI:       figi,
+		Interval:   a.interval,
+		OpenPrice:  candles[0].OpenPrice,
+		ClosePrice: candles[len(candles)-1].ClosePrice,
+		HighPrice:  candles[0].HighPrice,
+		LowPrice:   candles[0].LowPrice,
+		TS:         bar.start,
+	}
+	for _, c := range candles {
+		if c.HighPrice > result.HighPrice {
+			result.HighPrice = c.HighPrice
+		}
+		if c.LowPrice < result.LowPrice {
+			result.LowPrice = c.LowPrice
+		}
+		result.Volume += c.Volume
+	}
+
+	return result
+}
@@ -0,0 +1,80 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// OrderBookTracker keeps last orderbook by figi from orderbook events.
+// It is safe for concurrent use.
+type OrderBookTracker struct {
+	mu    sync.RWMutex
+	books map[string]trackedOrderBook
+}
+
+type trackedOrderBook struct {
+	book OrderBook
+	time time.Time
+}
+
+// NewOrderBookTracker returns empty OrderBookTracker.
+func NewOrderBookTracker() *OrderBookTracker {
+	return &OrderBookTracker{books: make(map[string]trackedOrderBook)}
+}
+
+// Apply stores orderbook of event. Events older than stored orderbook of figi are ignored.
+func (t *OrderBookTracker) Apply(event OrderBookEvent) {
+	figi := event.OrderBook.FIGI
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if current, ok := t.books[figi]; ok && event.Time.Before(current.time) {
+		return
+	}
+
+	t.books[figi] = trackedOrderBook{book: copyOrderBook(event.OrderBook), time: event.Time}
+}
+
+// Best returns best bid and ask of figi, ok is false when orderbook is unknown or any side is empty.
+func (t *OrderBookTracker) Best(figi string) (bid, ask PriceQuantity, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	current, found := t.books[figi]
+	if !found {
+		return PriceQuantity{}, PriceQuantity{}, false
+	}
+
+	bid, okBid := current.book.BestBid()
+	ask, okAsk := current.book.BestAsk()
+
+	return bid, ask, okBid && okAsk
+}
+
+// Snapshot returns copy of last orderbook of figi.
+func (t *OrderBookTracker) Snapshot(figi string) (OrderBook, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	current, ok := t.books[figi]
+	if !ok {
+		return OrderBook{}, false
+	}
+
+	return copyOrderBook(current.book), true
+}
+
+// Reset discards all stored orderbooks, e.g. after reconnect.
+func (t *OrderBookTracker) Reset() {
+	t.mu.Lock()
+	t.books = make(map[string]trackedOrderBook)
+	t.mu.Unlock()
+}
+
+func copyOrderBook(book OrderBook) OrderBook {
+	book.Bids = append([]PriceQuantity(nil), book.Bids...)
+	book.Asks = append([]PriceQuantity(nil), book.Asks...)
+
+	return book
+}
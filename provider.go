@@ -85,18 +85,24 @@ func (c *defaultHTTP) Get(ctx context.Context, url string, token string, unmarsh
 func (c *defaultHTTP) do(req *http.Request) (*http.Response, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("provider client do: %w", err)
+		return nil, fmt.Errorf("provider client do: %w", &NetworkError{Err: err})
 	}
 
 	switch resp.StatusCode {
 	case http.StatusOK:
 	case http.StatusNotFound:
 		return nil, ErrNotFound
+	case http.StatusTooManyRequests:
+		resp.Body.Close()
+
+		return nil, &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 	default:
-		tradingError := TradingError{}
+		defer resp.Body.Close()
+
+		tradingError := TradingError{HTTPStatus: resp.StatusCode}
 		err := json.NewDecoder(resp.Body).Decode(&tradingError)
 		if err != nil {
-			return nil, fmt.Errorf("json decode error: %w", err)
+			tradingError.Hint = fmt.Sprintf("json decode error: %s", err)
 		}
 
 		return nil, tradingError
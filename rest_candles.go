@@ -0,0 +1,203 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxConcurrentRequests limits count of simultaneous requests made by batch helpers.
+const MaxConcurrentRequests = 5
+
+// acquire takes slot of sem, it returns error of ctx when ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CandlesMultiError contains errors of CandlesMulti by figi.
+type CandlesMultiError map[string]error
+
+// Error for implements error.
+func (e CandlesMultiError) Error() string {
+	figis := make([]string, 0, len(e))
+	for figi := range e {
+		figis = append(figis, figi)
+	}
+	sort.Strings(figis)
+
+	parts := make([]string, 0, len(figis))
+	for _, figi := range figis {
+		parts = append(parts, figi+": "+e[figi].Error())
+	}
+
+	return fmt.Sprintf("can't get candles for %d figi: %s", len(e), strings.Join(parts, "; "))
+}
+
+// CandlesMaxPeriod returns max period of single candles request for interval
+// or 0 for unknown interval.
+func CandlesMaxPeriod(interval CandleInterval) time.Duration {
+	const day = 24 * time.Hour
+
+	switch interval {
+	case CandleInterval1Min, CandleInterval2Min, CandleInterval3Min, CandleInterval5Min,
+		CandleInterval10Min, CandleInterval15Min, CandleInterval30Min:
+		return day
+	case CandleInterval1Hour, CandleInterval2Hour, CandleInterval4Hour:
+		return 7 * day
+	case CandleInterval1Day:
+		return 365 * day
+	case CandleInterval1Week:
+		return 2 * 365 * day
+	case CandleInterval1Month:
+		return 10 * 365 * day
+	default:
+		return 0
+	}
+}
+
+// CandlesHistory returns candles of period [from, to) of any length sorted by time.
+// Period is split into CandlesMaxPeriod chunks requested concurrently,
+// at most MaxConcurrentRequests at once. ErrInterval returned for unknown interval.
+func (c *RestClient) CandlesHistory(
+	ctx context.Context,
+	figi string,
+	from, to time.Time,
+	interval CandleInterval,
+) ([]Candle, error) {
+	return c.candlesHistory(ctx, make(chan struct{}, MaxConcurrentRequests), figi, from, to, interval)
+}
+
+// candlesHistory is CandlesHistory making chunk requests by slots of sem.
+func (c *RestClient) candlesHistory(
+	ctx context.Context,
+	sem chan struct{},
+	figi string,
+	from, to time.Time,
+	interval CandleInterval,
+) ([]Candle, error) {
+	period := CandlesMaxPeriod(interval)
+	if period == 0 {
+		return nil, ErrInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		result   []Candle
+		firstErr error
+	)
+
+	for start := from; start.Before(to); start = start.Add(period) {
+		end := start.Add(period)
+		if end.After(to) {
+			end = to
+		}
+
+		if err := acquire(ctx, sem); err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end time.Time) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			candles, err := c.Candles(ctx, start, end, interval, figi)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			result = append(result, candles...)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TS.Before(result[j].TS)
+	})
+
+	unique := result[:0]
+	for i := range result {
+		if len(unique) > 0 && unique[len(unique)-1].TS.Equal(result[i].TS) {
+			continue
+		}
+		unique = append(unique, result[i])
+	}
+
+	return unique, nil
+}
+
+// CandlesMulti returns candles for several figis concurrently.
+// On failures it returns partial results and CandlesMultiError with errors by figi.
+// Long periods are split into chunks, see CandlesHistory, chunks of all figis share
+// MaxConcurrentRequests limit.
+func (c *RestClient) CandlesMulti(
+	ctx context.Context,
+	figis []string,
+	from, to time.Time,
+	interval CandleInterval,
+) (map[string][]Candle, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, MaxConcurrentRequests)
+		result  = make(map[string][]Candle, len(figis))
+		errsMap = make(CandlesMultiError)
+	)
+
+	for _, figi := range figis {
+		wg.Add(1)
+		go func(figi string) {
+			defer wg.Done()
+
+			candles, err := c.candlesHistory(ctx, sem, figi, from, to, interval)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errsMap[figi] = err
+				return
+			}
+			result[figi] = candles
+		}(figi)
+	}
+	wg.Wait()
+
+	if len(errsMap) > 0 {
+		return result, errsMap
+	}
+
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCandlesMultiConcurrency(t *testing.T) {
+	client, p := newStubClient()
+	p.delay = 5 * time.Millisecond
+	p.respond("/market/candles", `{"candles":[]}`)
+
+	figis := []string{"A", "B", "C", "D"}
+	to := time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-3 * CandlesMaxPeriod(CandleInterval1Min))
+
+	result, err := client.CandlesMulti(context.Background(), figis, from, to, CandleInterval1Min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(figis) {
+		t.Fatalf("candles of %d figis", len(result))
+	}
+	if n := p.count("/market/candles"); n != 3*len(figis) {
+		t.Fatalf("%d requests", n)
+	}
+	if p.maxInFlight > MaxConcurrentRequests {
+		t.Fatalf("%d simultaneous requests, want at most %d", p.maxInFlight, MaxConcurrentRequests)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.CandlesMulti(ctx, figis, from, to, CandleInterval1Min)
+	var multiErr CandlesMultiError
+	if !errors.As(err, &multiErr) || len(multiErr) != len(figis) || !errors.Is(multiErr["A"], context.Canceled) {
+		t.Fatalf("canceled ctx: %v", err)
+	}
+	if n := p.count("/market/candles"); n != 3*len(figis) {
+		t.Fatalf("%d requests after cancel", n-3*len(figis))
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -14,8 +16,45 @@ import (
 var (
 	ErrDepth    = fmt.Errorf("invalid depth. Should be in interval 0 < x <= %d", MaxOrderbookDepth)
 	ErrNotFound = errors.New("not found")
+	// ErrPriceOutOfLimits returned when order price is out of instrument limits, see PriceOutOfLimitsError.
+	ErrPriceOutOfLimits = errors.New("price out of limits")
+	// ErrInvalidOrder returned when order is rejected by local validation before request, see InvalidOrderError.
+	ErrInvalidOrder = errors.New("invalid order")
 )
 
+// InvalidOrderError contains invalid field of order.
+type InvalidOrderError struct {
+	Field  string
+	Reason string
+}
+
+// Error for implements error.
+func (e InvalidOrderError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidOrder, e.Field, e.Reason)
+}
+
+// Is for errors.Is with ErrInvalidOrder.
+func (e InvalidOrderError) Is(target error) bool {
+	return target == ErrInvalidOrder
+}
+
+// PriceOutOfLimitsError contains allowed price band of instrument.
+type PriceOutOfLimitsError struct {
+	Price     float64
+	LimitDown float64
+	LimitUp   float64
+}
+
+// Error for implements error.
+func (e PriceOutOfLimitsError) Error() string {
+	return fmt.Sprintf("%s: price %v, allowed [%v, %v]", ErrPriceOutOfLimits, e.Price, e.LimitDown, e.LimitUp)
+}
+
+// Is for errors.Is with ErrPriceOutOfLimits.
+func (e PriceOutOfLimitsError) Is(target error) bool {
+	return target == ErrPriceOutOfLimits
+}
+
 const (
 	// RestAPIURL contains main api url for tinkoff invest api.
 	RestAPIURL = "https://invest-public-api.tinkoff.ru/openapi"
@@ -26,15 +65,51 @@ const (
 type (
 	// RestClient provide to rest methods from tinkoff invest api.
 	RestClient struct {
-		provider Provider
-		token    string
-		url      string
+		provider    Provider
+		tokenMu     sync.RWMutex
+		token       string
+		url         string
+		instruments *InstrumentCache
+		retry       *RetryConfig
+		rateLimit   int
 	}
 
 	// BuildOption build options for rest client.
 	BuildOption func(*RestClient)
+
+	// CallOption options for single rest client call.
+	CallOption func(*callOptions)
+
+	callOptions struct {
+		checkPriceLimits  bool
+		keepOnContextDone bool
+	}
 )
 
+// WithPriceLimitsCheck validates order price against instrument LimitUp/LimitDown before placing an order.
+// Limits are fetched from orderbook, so it costs one more request.
+func WithPriceLimitsCheck() CallOption {
+	return func(o *callOptions) {
+		o.checkPriceLimits = true
+	}
+}
+
+// WithoutCancelOnContextDone keeps order active when context is done while waiting for order, see LimitOrderAndAwait.
+func WithoutCancelOnContextDone() CallOption {
+	return func(o *callOptions) {
+		o.keepOnContextDone = true
+	}
+}
+
+func buildCallOptions(options []CallOption) callOptions {
+	var o callOptions
+	for i := range options {
+		options[i](&o)
+	}
+
+	return o
+}
+
 // WithProvider build rest client by custom provider client.
 func WithProvider(p Provider) BuildOption {
 	return func(client *RestClient) {
@@ -42,6 +117,18 @@ func WithProvider(p Provider) BuildOption {
 	}
 }
 
+// WithHTTPClient build rest client by custom http client of default provider.
+// Client without timeout is used as is, so set Timeout or use contexts with deadline.
+// Nil client is ignored, default client with MaxTimeout is used then.
+func WithHTTPClient(httpClient *http.Client) BuildOption {
+	return func(client *RestClient) {
+		if httpClient == nil {
+			return
+		}
+		client.provider = &defaultHTTP{client: httpClient}
+	}
+}
+
 // WithURL build rest client by custom api url.
 func WithURL(url string) BuildOption {
 	return func(client *RestClient) {
@@ -66,6 +153,14 @@ func NewRestClient(token string, options ...BuildOption) *RestClient {
 		options[i](client)
 	}
 
+	if client.rateLimit > 0 {
+		client.provider = &rateLimitProvider{next: client.provider, bucket: newTokenBucket(client.rateLimit)}
+	}
+	if client.retry != nil {
+		client.provider = &retryProvider{next: client.provider, cfg: *client.retry}
+	}
+	client.instruments = NewInstrumentCache(client)
+
 	return client
 }
 
@@ -75,6 +170,20 @@ func NewRestClientCustom(token, apiURL string) *RestClient {
 	return NewRestClient(token, WithURL(apiURL))
 }
 
+// SetToken sets token used by next requests, it is safe to call concurrently with requests.
+func (c *RestClient) SetToken(token string) {
+	c.tokenMu.Lock()
+	c.token = token
+	c.tokenMu.Unlock()
+}
+
+func (c *RestClient) getToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
+	return c.token
+}
+
 // InstrumentByFIGI see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/market/get_market_search_by_figi.
 func (c *RestClient) InstrumentByFIGI(ctx context.Context, figi string) (Instrument, error) {
 	var response struct {
@@ -82,7 +191,7 @@ func (c *RestClient) InstrumentByFIGI(ctx context.Context, figi string) (Instrum
 	}
 
 	path := c.url + "/market/search/by-figi?figi=" + figi
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return Instrument{}, fmt.Errorf("provider get: %w", err)
 	}
@@ -98,9 +207,9 @@ func (c *RestClient) InstrumentByTicker(ctx context.Context, ticker string) ([]I
 		} `json:"payload"`
 	}
 
-	path := c.url + "/market/search/by-ticker?ticker=" + ticker
+	path := c.url + "/market/search/by-ticker?ticker=" + url.QueryEscape(ticker)
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -109,6 +218,7 @@ func (c *RestClient) InstrumentByTicker(ctx context.Context, ticker string) ([]I
 }
 
 // Currencies see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/market/get_market_currencies.
+// Instruments are sorted by ticker.
 func (c *RestClient) Currencies(ctx context.Context) ([]Instrument, error) {
 	var response struct {
 		Payload struct {
@@ -118,12 +228,17 @@ func (c *RestClient) Currencies(ctx context.Context) ([]Instrument, error) {
 
 	path := c.url + "/market/currencies"
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
 
-	return response.Payload.Instruments, nil
+	instruments := response.Payload.Instruments
+	sort.SliceStable(instruments, func(i, j int) bool {
+		return instruments[i].Ticker < instruments[j].Ticker
+	})
+
+	return instruments, nil
 }
 
 // ETFs see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/market/get_market_etfs.
@@ -136,7 +251,7 @@ func (c *RestClient) ETFs(ctx context.Context) ([]Instrument, error) {
 
 	path := c.url + "/market/etfs"
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -154,7 +269,7 @@ func (c *RestClient) Bonds(ctx context.Context) ([]Instrument, error) {
 
 	path := c.url + "/market/bonds"
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -172,7 +287,7 @@ func (c *RestClient) Stocks(ctx context.Context) ([]Instrument, error) {
 
 	path := c.url + "/market/stocks"
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -181,10 +296,10 @@ func (c *RestClient) Stocks(ctx context.Context) ([]Instrument, error) {
 }
 
 // Operations see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/operations/get_operations.
-func (c *RestClient) Operations(ctx context.Context, accountID string, from, to time.Time, figi string) ([]Operation, error) {
+func (c *RestClient) Operations(ctx context.Context, accountID string, from, to time.Time, figi string) (Operations, error) {
 	var response struct {
 		Payload struct {
-			Operations []Operation `json:"operations"`
+			Operations Operations `json:"operations"`
 		} `json:"payload"`
 	}
 
@@ -201,7 +316,7 @@ func (c *RestClient) Operations(ctx context.Context, accountID string, from, to
 
 	path := c.url + "/operations?" + q.Encode()
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -241,7 +356,7 @@ func (c *RestClient) PositionsPortfolio(ctx context.Context, accountID string) (
 		path += "?brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -263,7 +378,7 @@ func (c *RestClient) CurrenciesPortfolio(ctx context.Context, accountID string)
 		path += "?brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -278,7 +393,7 @@ func (c *RestClient) OrderCancel(ctx context.Context, accountID, id string) erro
 		path += "&brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Post(ctx, path, c.token, nil, nil)
+	err := c.provider.Post(ctx, path, c.getToken(), nil, nil)
 	if err != nil {
 		return fmt.Errorf("provider post: %w", err)
 	}
@@ -287,17 +402,32 @@ func (c *RestClient) OrderCancel(ctx context.Context, accountID, id string) erro
 }
 
 // LimitOrder see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/orders/post_orders_limit_order.
+// Empty figi, non-positive lots or price and operation other than BUY or SELL are rejected with InvalidOrderError.
 func (c *RestClient) LimitOrder(
 	ctx context.Context,
 	accountID, figi string,
 	lots int,
 	operation OperationType,
 	price float64,
+	options ...CallOption,
 ) (PlacedOrder, error) {
 	var response struct {
 		Payload PlacedOrder `json:"payload"`
 	}
 
+	if err := validateOrder(figi, lots, operation); err != nil {
+		return PlacedOrder{}, err
+	}
+	if price <= 0 {
+		return PlacedOrder{}, InvalidOrderError{Field: "price", Reason: "should be positive"}
+	}
+
+	if buildCallOptions(options).checkPriceLimits {
+		if err := c.checkPriceLimits(ctx, figi, price); err != nil {
+			return PlacedOrder{}, err
+		}
+	}
+
 	path := c.url + "/orders/limit-order?figi=" + figi
 
 	if accountID != DefaultAccount {
@@ -310,7 +440,7 @@ func (c *RestClient) LimitOrder(
 		Price     float64       `json:"price"`
 	}{Lots: lots, Operation: operation, Price: price}
 
-	err := c.provider.Post(ctx, path, c.token, payload, &response)
+	err := c.provider.Post(ctx, path, c.getToken(), payload, &response)
 	if err != nil {
 		return PlacedOrder{}, fmt.Errorf("provider post: %w", err)
 	}
@@ -318,12 +448,43 @@ func (c *RestClient) LimitOrder(
 	return response.Payload, nil
 }
 
+func validateOrder(figi string, lots int, operation OperationType) error {
+	switch {
+	case figi == "":
+		return InvalidOrderError{Field: "figi", Reason: "is empty"}
+	case lots <= 0:
+		return InvalidOrderError{Field: "lots", Reason: "should be positive"}
+	case operation != BUY && operation != SELL:
+		return InvalidOrderError{Field: "operation", Reason: "should be Buy or Sell"}
+	}
+
+	return nil
+}
+
+func (c *RestClient) checkPriceLimits(ctx context.Context, figi string, price float64) error {
+	orderbook, err := c.Orderbook(ctx, 1, figi)
+	if err != nil {
+		return fmt.Errorf("get limits: %w", err)
+	}
+
+	if (orderbook.LimitDown != 0 && price < orderbook.LimitDown) || (orderbook.LimitUp != 0 && price > orderbook.LimitUp) {
+		return PriceOutOfLimitsError{Price: price, LimitDown: orderbook.LimitDown, LimitUp: orderbook.LimitUp}
+	}
+
+	return nil
+}
+
 // MarketOrder see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/orders/post_orders_market_order.
+// Empty figi, non-positive lots and operation other than BUY or SELL are rejected with InvalidOrderError.
 func (c *RestClient) MarketOrder(ctx context.Context, accountID, figi string, lots int, operation OperationType) (PlacedOrder, error) {
 	var response struct {
 		Payload PlacedOrder `json:"payload"`
 	}
 
+	if err := validateOrder(figi, lots, operation); err != nil {
+		return PlacedOrder{}, err
+	}
+
 	path := c.url + "/orders/market-order?figi=" + figi
 
 	if accountID != DefaultAccount {
@@ -335,7 +496,7 @@ func (c *RestClient) MarketOrder(ctx context.Context, accountID, figi string, lo
 		Operation OperationType `json:"operation"`
 	}{Lots: lots, Operation: operation}
 
-	err := c.provider.Post(ctx, path, c.token, payload, &response)
+	err := c.provider.Post(ctx, path, c.getToken(), payload, &response)
 	if err != nil {
 		return PlacedOrder{}, fmt.Errorf("provider post: %w", err)
 	}
@@ -355,7 +516,7 @@ func (c *RestClient) Orders(ctx context.Context, accountID string) ([]Order, err
 		path += "?brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -381,7 +542,7 @@ func (c *RestClient) Candles(ctx context.Context, from, to time.Time, interval C
 	}
 	path := c.url + "/market/candles?" + q.Encode()
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
@@ -405,7 +566,7 @@ func (c *RestClient) Orderbook(ctx context.Context, depth int, figi string) (Res
 	}
 	path := c.url + "/market/orderbook?" + q.Encode()
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return RestOrderBook{}, fmt.Errorf("provider get: %w", err)
 	}
@@ -423,7 +584,7 @@ func (c *RestClient) Accounts(ctx context.Context) ([]Account, error) {
 
 	path := c.url + "/user/accounts"
 
-	err := c.provider.Get(ctx, path, c.token, &response)
+	err := c.provider.Get(ctx, path, c.getToken(), &response)
 	if err != nil {
 		return nil, fmt.Errorf("provider get: %w", err)
 	}
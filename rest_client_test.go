@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubProvider responds with JSON bodies by request path without query,
+// body of every path is its payload and ErrNotFound is returned for unknown paths.
+type stubProvider struct {
+	// delay of every request, max count of simultaneous requests is tracked with it
+	delay time.Duration
+
+	mu          sync.Mutex
+	responses   map[string][]string
+	requests    []string
+	inFlight    int
+	maxInFlight int
+}
+
+func newStubProvider() *stubProvider {
+	return &stubProvider{responses: make(map[string][]string)}
+}
+
+// respond adds payloads returned by next requests of path, the last one is repeated.
+func (p *stubProvider) respond(path string, payloads ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.responses[path] = append(p.responses[path], payloads...)
+}
+
+func (p *stubProvider) Get(_ context.Context, url string, _ string, unmarshal interface{}) error {
+	return p.do(url, unmarshal)
+}
+
+func (p *stubProvider) Post(_ context.Context, url string, _ string, _, unmarshal interface{}) error {
+	return p.do(url, unmarshal)
+}
+
+func (p *stubProvider) do(url string, unmarshal interface{}) error {
+	path := strings.SplitN(url, "?", 2)[0]
+
+	p.mu.Lock()
+	p.requests = append(p.requests, url)
+	payloads := p.responses[path]
+	if len(payloads) > 1 {
+		p.responses[path] = payloads[1:]
+	}
+	p.inFlight++
+	if p.inFlight > p.maxInFlight {
+		p.maxInFlight = p.inFlight
+	}
+	p.mu.Unlock()
+
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+
+	if len(payloads) == 0 {
+		return ErrNotFound
+	}
+	if unmarshal == nil {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(`{"payload":`+payloads[0]+`}`), unmarshal)
+}
+
+func (p *stubProvider) count(path string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	for _, url := range p.requests {
+		if strings.SplitN(url, "?", 2)[0] == path {
+			n++
+		}
+	}
+
+	return n
+}
+
+func newStubClient() (*RestClient, *stubProvider) {
+	p := newStubProvider()
+
+	return NewRestClient("token", WithProvider(p), WithURL("")), p
+}
+
+func TestCurrenciesOrder(t *testing.T) {
+	client, p := newStubClient()
+	p.respond("/market/currencies", `{"instruments":[
+		{"figi":"BBG0013HGFT4","ticker":"USD000UTSTOM","minPriceIncrement":0.0025,"lot":1000,"minQuantity":1,"nominal":1,"currency":"RUB","type":"Currency"},
+		{"figi":"BBG0013HJJ31","ticker":"EUR_RUB__TOM","minPriceIncrement":0.0025,"lot":1000,"currency":"RUB","type":"Currency"},
+		{"figi":"BBG0013HRTL0","ticker":"CNYRUB_TOM","minPriceIncrement":0.0001,"lot":1000,"nominal":1,"currency":"RUB","type":"Currency"}
+	]}`)
+
+	for i := 0; i < 3; i++ {
+		currencies, err := client.Currencies(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tickers []string
+		for _, currency := range currencies {
+			tickers = append(tickers, currency.Ticker)
+		}
+		if len(tickers) != 3 || tickers[0] != "CNYRUB_TOM" || tickers[1] != "EUR_RUB__TOM" || tickers[2] != "USD000UTSTOM" {
+			t.Fatalf("unexpected order %v", tickers)
+		}
+
+		usd := currencies[2]
+		if usd.Currency != RUB || usd.Nominal != 1 || usd.MinQuantity != 1 || usd.MinPriceIncrement != 0.0025 {
+			t.Fatalf("unexpected fields %+v", usd)
+		}
+	}
+}
+
+func TestWithNilHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"payload":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewRestClient("token", WithHTTPClient(nil), WithURL(srv.URL))
+	provider, ok := client.provider.(*defaultHTTP)
+	if !ok || provider.client == nil || provider.client.Timeout != MaxTimeout {
+		t.Fatalf("provider %+v isn't default", client.provider)
+	}
+	if _, err := client.Orders(context.Background(), DefaultAccount); err != nil {
+		t.Fatalf("request with default client: %v", err)
+	}
+}
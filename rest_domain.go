@@ -100,6 +100,9 @@ type Order struct {
 	ExecutedLots  int           `json:"executedLots"`
 	Type          OrderType     `json:"type"`
 	Price         float64       `json:"price"`
+
+	raw        string
+	exactPrice Decimal
 }
 
 type Portfolio struct {
@@ -107,12 +110,16 @@ type Portfolio struct {
 	Currencies []CurrencyBalance
 }
 
+// CurrencyBalance contains balance of currency. Use BalanceDecimal for exact value.
 type CurrencyBalance struct {
 	Currency Currency `json:"currency"`
 	Balance  float64  `json:"balance"`
 	Blocked  float64  `json:"blocked"`
+
+	exactBalance Decimal
 }
 
+// PositionBalance contains position of portfolio. Use BalanceDecimal for exact value.
 type PositionBalance struct {
 	FIGI                      string         `json:"figi"`
 	Ticker                    string         `json:"ticker"`
@@ -125,13 +132,19 @@ type PositionBalance struct {
 	AveragePositionPrice      MoneyAmount    `json:"averagePositionPrice"`
 	AveragePositionPriceNoNkd MoneyAmount    `json:"averagePositionPriceNoNkd"`
 	Name                      string         `json:"name"`
+
+	exactBalance Decimal
 }
 
+// MoneyAmount contains amount of money in currency. Use Decimal for exact value.
 type MoneyAmount struct {
 	Currency Currency `json:"currency"`
 	Value    float64  `json:"value"`
+	exact    Decimal
 }
 
+// Instrument is market instrument. MinQuantity is minimum quantity of currency order and
+// Nominal is nominal of instrument, e.g. of currency, they are zero when api doesn't send them.
 type Instrument struct {
 	FIGI              string         `json:"figi"`
 	Ticker            string         `json:"ticker"`
@@ -139,8 +152,13 @@ type Instrument struct {
 	Name              string         `json:"name"`
 	MinPriceIncrement float64        `json:"minPriceIncrement"`
 	Lot               int            `json:"lot"`
+	MinQuantity       int            `json:"minQuantity,omitempty"`
+	Nominal           float64        `json:"nominal,omitempty"`
 	Currency          Currency       `json:"currency"`
 	Type              InstrumentType `json:"type"`
+
+	raw                    string
+	exactMinPriceIncrement Decimal
 }
 
 type Operation struct {
@@ -158,6 +176,44 @@ type Operation struct {
 	IsMarginCall     bool            `json:"isMarginCall"`
 	DateTime         time.Time       `json:"date"`
 	OperationType    OperationType   `json:"operationType"`
+
+	raw        string
+	exactPrice Decimal
+}
+
+type Operations []Operation
+
+// Done returns only completed operations. Declined and in progress operations
+// can't be trusted for amounts, so financial aggregations use Done internally.
+func (o Operations) Done() Operations {
+	done := make(Operations, 0, len(o))
+	for i := range o {
+		if o[i].Status == OperationStatusDone {
+			done = append(done, o[i])
+		}
+	}
+
+	return done
+}
+
+// Payments returns sum of payments of completed operations grouped by currency.
+func (o Operations) Payments() map[Currency]float64 {
+	payments := make(map[Currency]float64)
+	for _, op := range o.Done() {
+		payments[op.Currency] += op.Payment
+	}
+
+	return payments
+}
+
+// Commissions returns sum of commissions of completed operations grouped by currency.
+func (o Operations) Commissions() map[Currency]float64 {
+	commissions := make(map[Currency]float64)
+	for _, op := range o.Done() {
+		commissions[op.Commission.Currency] += op.Commission.Value
+	}
+
+	return commissions
 }
 
 type Trade struct {
@@ -0,0 +1,38 @@
+package sdk
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOperationsExcludeDeclined(t *testing.T) {
+	client, p := newStubClient()
+	p.respond("/operations", `{"operations":[
+		{"id":"1","status":"Done","currency":"RUB","payment":-1000,"commission":{"currency":"RUB","value":-3}},
+		{"id":"2","status":"Decline","currency":"RUB","payment":-5000,"commission":{"currency":"RUB","value":-15}},
+		{"id":"3","status":"Progress","currency":"USD","payment":-10,"commission":{"currency":"USD","value":-0.1}},
+		{"id":"4","status":"Done","currency":"USD","payment":20,"commission":{"currency":"USD","value":-0.2}}
+	]}`)
+
+	to := time.Now()
+	operations, err := client.Operations(context.Background(), DefaultAccount, to.Add(-time.Hour), to, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operations[1].Status != OperationStatusDecline || operations[2].Status != OperationStatusProgress {
+		t.Fatalf("statuses %s, %s", operations[1].Status, operations[2].Status)
+	}
+
+	done := operations.Done()
+	if len(done) != 2 || done[0].ID != "1" || done[1].ID != "4" {
+		t.Fatalf("done operations %+v", done)
+	}
+	if got, want := operations.Payments(), map[Currency]float64{RUB: -1000, USD: 20}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("payments %v, want %v", got, want)
+	}
+	if got, want := operations.Commissions(), map[Currency]float64{RUB: -3, USD: -0.2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commissions %v, want %v", got, want)
+	}
+}
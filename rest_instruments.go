@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrAmbiguousTicker returned by ResolveFIGI when several instruments have ticker.
+var ErrAmbiguousTicker = errors.New("ambiguous ticker")
+
+// InstrumentCache memoizes instruments by figi. It is safe for concurrent use.
+type InstrumentCache struct {
+	client *RestClient
+
+	mu          sync.RWMutex
+	instruments map[string]Instrument
+}
+
+// NewInstrumentCache returns new InstrumentCache which loads instruments by client.
+func NewInstrumentCache(client *RestClient) *InstrumentCache {
+	return &InstrumentCache{
+		client:      client,
+		instruments: make(map[string]Instrument),
+	}
+}
+
+// Resolve returns instrument by figi, instrument is requested from api only once.
+func (c *InstrumentCache) Resolve(ctx context.Context, figi string) (Instrument, error) {
+	c.mu.RLock()
+	instrument, ok := c.instruments[figi]
+	c.mu.RUnlock()
+
+	if ok {
+		return instrument, nil
+	}
+
+	instrument, err := c.client.InstrumentByFIGI(ctx, figi)
+	if err != nil {
+		return Instrument{}, err
+	}
+
+	c.mu.Lock()
+	c.instruments[figi] = instrument
+	c.mu.Unlock()
+
+	return instrument, nil
+}
+
+// Resolve returns instrument by figi using client instruments cache.
+// It is safe for concurrent use, e.g. from streaming client callback.
+func (c *RestClient) Resolve(ctx context.Context, figi string) (Instrument, error) {
+	return c.instruments.Resolve(ctx, figi)
+}
+
+// SearchInstruments returns instruments by exact ticker or, when there are none,
+// instruments which ticker or name contains query ignoring case.
+// API has no search by name, so name search loads all stocks, bonds, etfs and currencies, it costs 5 requests.
+func (c *RestClient) SearchInstruments(ctx context.Context, query string) ([]Instrument, error) {
+	instruments, err := c.InstrumentByTicker(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(instruments) > 0 {
+		return instruments, nil
+	}
+
+	query = strings.ToLower(query)
+
+	var found []Instrument
+	for _, list := range []func(context.Context) ([]Instrument, error){c.Stocks, c.Bonds, c.ETFs, c.Currencies} {
+		instruments, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instrument := range instruments {
+			if strings.Contains(strings.ToLower(instrument.Ticker), query) ||
+				strings.Contains(strings.ToLower(instrument.Name), query) {
+				found = append(found, instrument)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// ResolveFIGI returns figi of instrument by ticker. ErrNotFound returned when there is no instrument
+// and ErrAmbiguousTicker when there are several ones.
+func (c *RestClient) ResolveFIGI(ctx context.Context, ticker string) (string, error) {
+	instruments, err := c.InstrumentByTicker(ctx, ticker)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(instruments) {
+	case 0:
+		return "", fmt.Errorf("ticker %s: %w", ticker, ErrNotFound)
+	case 1:
+		return instruments[0].FIGI, nil
+	default:
+		return "", fmt.Errorf("ticker %s has %d instruments: %w", ticker, len(instruments), ErrAmbiguousTicker)
+	}
+}
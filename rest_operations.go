@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// OperationsAllPeriod is time window of single request made by OperationsAll.
+const OperationsAllPeriod = 30 * 24 * time.Hour
+
+// OperationsAll returns all operations of period [from, to) sorted by date.
+// Period is split into OperationsAllPeriod windows, one request per window,
+// so a year costs 13 requests. API doesn't report truncated responses, so windows
+// aren't shrunk and response of window is trusted to be complete. Operations of
+// adjacent windows are deduplicated by ID, operations without ID, e.g. some service
+// fees and declined operations, are never merged.
+func (c *RestClient) OperationsAll(ctx context.Context, accountID string, from, to time.Time, figi string) (Operations, error) {
+	seen := make(map[string]struct{})
+
+	var all Operations
+	for start := from; start.Before(to); start = start.Add(OperationsAllPeriod) {
+		end := start.Add(OperationsAllPeriod)
+		if end.After(to) {
+			end = to
+		}
+
+		operations, err := c.Operations(ctx, accountID, start, end, figi)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, op := range operations {
+			if op.ID != "" {
+				if _, ok := seen[op.ID]; ok {
+					continue
+				}
+				seen[op.ID] = struct{}{}
+			}
+			all = append(all, op)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].DateTime.Before(all[j].DateTime)
+	})
+
+	return all, nil
+}
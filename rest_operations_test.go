@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOperationsAll(t *testing.T) {
+	client, p := newStubClient()
+	p.respond("/operations",
+		`{"operations":[
+			{"id":"1","status":"Done","date":"2020-01-31T10:00:00Z","operationType":"Buy"},
+			{"status":"Done","date":"2020-01-10T10:00:00Z","operationType":"ServiceCommission","payment":-99}
+		]}`,
+		`{"operations":[
+			{"id":"1","status":"Done","date":"2020-01-31T10:00:00Z","operationType":"Buy"},
+			{"status":"Done","date":"2020-02-10T10:00:00Z","operationType":"ServiceCommission","payment":-99}
+		]}`,
+	)
+
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	operations, err := client.OperationsAll(context.Background(), DefaultAccount, from, from.Add(2*OperationsAllPeriod), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.count("/operations"); n != 2 {
+		t.Fatalf("%d requests, want 2", n)
+	}
+	if len(operations) != 3 {
+		t.Fatalf("operations %+v", operations)
+	}
+	if operations[0].ID != "" || operations[1].ID != "1" || operations[2].ID != "" || !operations[2].DateTime.After(operations[1].DateTime) {
+		t.Fatalf("unexpected order %+v", operations)
+	}
+}
@@ -0,0 +1,340 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOrderNotActive returned when order is already filled or cancelled.
+var ErrOrderNotActive = errors.New("order is not active")
+
+// ErrOrderStatusUnknown returned by AwaitOrder when order isn't active anymore, but its done operation
+// isn't found after FinishedOrderPolls polls. Order is cancelled without trades or its operation isn't visible yet.
+var ErrOrderStatusUnknown = errors.New("order status is unknown")
+
+// FinishedOrderPolls is count of operations polls made by AwaitOrder for order which isn't active anymore.
+const FinishedOrderPolls = 3
+
+// OrderFillsPeriod is period before now where OrderFills looks for operations of order.
+const OrderFillsPeriod = 7 * 24 * time.Hour
+
+// Fill is single trade of executed order.
+type Fill struct {
+	Time       time.Time
+	Price      float64
+	Quantity   int
+	Commission MoneyAmount
+}
+
+// OrderFills returns trades of order with their prices.
+// API doesn't link operations to orders explicitly: swagger schema has no order id in operation,
+// but api uses orderId returned by LimitOrder and MarketOrder as id of Buy or Sell operation created
+// by order. So operation is matched by id and its type, operations of other types are skipped.
+// There is no fallback to matching by figi and time window: OrderFills knows only order id, figi of
+// finished order isn't available from api, and operations of same figi can belong to other orders,
+// so their trades would be reported as fills of order. ErrNotFound is returned when operation isn't found.
+// Only operations of last OrderFillsPeriod are looked up. Commission of operation
+// is split between trades proportionally to quantity.
+func (c *RestClient) OrderFills(ctx context.Context, accountID, orderID string) ([]Fill, error) {
+	op, err := c.orderOperation(ctx, accountID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return operationFills(op), nil
+}
+
+// LimitOrderRequest contains parameters of limit order.
+type LimitOrderRequest struct {
+	FIGI      string
+	Lots      int
+	Operation OperationType
+	Price     float64
+}
+
+// AwaitOrder polls active orders every poll until order reaches terminal status.
+// Active orders don't contain executed and cancelled orders, so when order disappears
+// its status and ExecutedLots are taken from operation of order: Fill when all lots are executed,
+// Cancelled when part of them is executed and Rejected for declined operation. When there is no
+// operation of order, last seen order is returned with ErrOrderStatusUnknown.
+func (c *RestClient) AwaitOrder(ctx context.Context, accountID, orderID string, poll time.Duration) (Order, error) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	last := Order{ID: orderID}
+	for {
+		orders, err := c.Orders(ctx, accountID)
+		if err != nil {
+			return last, err
+		}
+
+		found := false
+		for _, order := range orders {
+			if order.ID == orderID {
+				last, found = order, true
+				break
+			}
+		}
+
+		if found && isTerminalOrderStatus(last.Status) {
+			return last, nil
+		}
+		if !found {
+			return c.finishedOrder(ctx, accountID, last, poll)
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// LimitOrderAndAwait places limit order and waits until it reaches terminal status, see AwaitOrder.
+// When ctx is done before, order is cancelled, use WithoutCancelOnContextDone to disable it.
+func (c *RestClient) LimitOrderAndAwait(
+	ctx context.Context,
+	accountID string,
+	req LimitOrderRequest,
+	poll time.Duration,
+	options ...CallOption,
+) (Order, error) {
+	placed, err := c.LimitOrder(ctx, accountID, req.FIGI, req.Lots, req.Operation, req.Price, options...)
+	if err != nil {
+		return Order{}, err
+	}
+
+	order := Order{
+		ID:            placed.ID,
+		FIGI:          req.FIGI,
+		Operation:     placed.Operation,
+		Status:        placed.Status,
+		RequestedLots: placed.RequestedLots,
+		ExecutedLots:  placed.ExecutedLots,
+		Type:          OrderTypeLimit,
+		Price:         req.Price,
+	}
+	if isTerminalOrderStatus(order.Status) {
+		return order, nil
+	}
+
+	awaited, err := c.AwaitOrder(ctx, accountID, placed.ID, poll)
+	if err != nil && ctx.Err() != nil && !buildCallOptions(options).keepOnContextDone {
+		cancelCtx, cancel := context.WithTimeout(context.Background(), MaxTimeout)
+		defer cancel()
+
+		if cancelErr := c.OrderCancel(cancelCtx, accountID, placed.ID); cancelErr != nil {
+			return awaited, fmt.Errorf("%w, cancel order: %v", err, cancelErr)
+		}
+	}
+
+	return awaited, err
+}
+
+func (c *RestClient) finishedOrder(ctx context.Context, accountID string, order Order, poll time.Duration) (Order, error) {
+	var (
+		op  Operation
+		err error
+	)
+	// operation of just filled order can be not visible yet, so it is polled several times
+	for i := 0; i < FinishedOrderPolls; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return order, ctx.Err()
+			case <-time.After(poll):
+			}
+		}
+
+		op, err = c.orderOperation(ctx, accountID, order.ID)
+		if err == nil && op.Status != OperationStatusProgress {
+			break
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return order, err
+		}
+	}
+	if err != nil {
+		return order, fmt.Errorf("%w: %v", ErrOrderStatusUnknown, err)
+	}
+	if op.Status == OperationStatusProgress {
+		return order, fmt.Errorf("%w: operation of order %s is in progress", ErrOrderStatusUnknown, order.ID)
+	}
+
+	lots, err := c.executedLots(ctx, op)
+	if err != nil {
+		return order, err
+	}
+	order.ExecutedLots = lots
+
+	switch {
+	case op.Status == OperationStatusDecline:
+		order.Status = OrderStatusRejected
+	case lots > 0 && lots >= order.RequestedLots:
+		order.Status = OrderStatusFill
+	default:
+		// order is cancelled after partial fill
+		order.Status = OrderStatusCancelled
+	}
+
+	return order, nil
+}
+
+// executedLots converts executed quantity of operation in pieces to lots of instrument.
+func (c *RestClient) executedLots(ctx context.Context, op Operation) (int, error) {
+	if op.QuantityExecuted == 0 {
+		return 0, nil
+	}
+
+	instrument, err := c.Resolve(ctx, op.FIGI)
+	if err != nil {
+		return 0, fmt.Errorf("resolve instrument %s: %w", op.FIGI, err)
+	}
+	if instrument.Lot <= 1 {
+		return op.QuantityExecuted, nil
+	}
+
+	return op.QuantityExecuted / instrument.Lot, nil
+}
+
+func isTerminalOrderStatus(status OrderStatus) bool {
+	return status == OrderStatusFill || status == OrderStatusCancelled || status == OrderStatusRejected
+}
+
+func (c *RestClient) orderOperation(ctx context.Context, accountID, orderID string) (Operation, error) {
+	to := time.Now()
+
+	operations, err := c.Operations(ctx, accountID, to.Add(-OrderFillsPeriod), to, "")
+	if err != nil {
+		return Operation{}, err
+	}
+
+	for _, op := range operations {
+		if op.ID == orderID && isTradeOperation(op.OperationType) {
+			return op, nil
+		}
+	}
+
+	return Operation{}, fmt.Errorf("operation of order %s: %w", orderID, ErrNotFound)
+}
+
+func isTradeOperation(operation OperationType) bool {
+	return operation == BUY || operation == SELL || operation == OperationTypeBuyCard
+}
+
+func operationFills(op Operation) []Fill {
+	var quantity int
+	for _, trade := range op.Trades {
+		quantity += trade.Quantity
+	}
+
+	fills := make([]Fill, 0, len(op.Trades))
+	for _, trade := range op.Trades {
+		commission := MoneyAmount{Currency: op.Commission.Currency}
+		if quantity > 0 {
+			commission.Value = op.Commission.Value * float64(trade.Quantity) / float64(quantity)
+		}
+
+		fills = append(fills, Fill{
+			Time:       trade.DateTime,
+			Price:      trade.Price,
+			Quantity:   trade.Quantity,
+			Commission: commission,
+		})
+	}
+
+	return fills
+}
+
+// CancelResult is result of cancel of single order.
+type CancelResult struct {
+	OrderID string
+	Err     error
+}
+
+// CancelOrders cancels orders concurrently and returns result for each order in orderIDs order.
+// When cancel fails and order is absent in active orders, result error matches ErrOrderNotActive,
+// it is not fatal. Returned error is not nil only if active orders can't be fetched.
+func (c *RestClient) CancelOrders(ctx context.Context, accountID string, orderIDs []string) ([]CancelResult, error) {
+	results := make([]CancelResult, len(orderIDs))
+
+	var (
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, MaxConcurrentRequests)
+		failed bool
+	)
+
+	for i := range orderIDs {
+		results[i].OrderID = orderIDs[i]
+		if err := acquire(ctx, sem); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *CancelResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result.Err = c.OrderCancel(ctx, accountID, result.OrderID)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	for i := range results {
+		if results[i].Err != nil {
+			failed = true
+		}
+	}
+	if !failed {
+		return results, nil
+	}
+
+	orders, err := c.Orders(ctx, accountID)
+	if err != nil {
+		return results, fmt.Errorf("get active orders: %w", err)
+	}
+
+	active := make(map[string]struct{}, len(orders))
+	for _, order := range orders {
+		active[order.ID] = struct{}{}
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			continue
+		}
+		if _, ok := active[results[i].OrderID]; !ok {
+			results[i].Err = fmt.Errorf("%w: %v", ErrOrderNotActive, results[i].Err)
+		}
+	}
+
+	return results, nil
+}
+
+// CancelAllOrders cancels all active orders of account concurrently, see CancelOrders.
+// Orders which became inactive before cancel, e.g. filled, are treated as cancelled, so their Err is nil.
+func (c *RestClient) CancelAllOrders(ctx context.Context, accountID string) ([]CancelResult, error) {
+	orders, err := c.Orders(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("get active orders: %w", err)
+	}
+
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	results, err := c.CancelOrders(ctx, accountID, orderIDs)
+	for i := range results {
+		if errors.Is(results[i].Err, ErrOrderNotActive) {
+			results[i].Err = nil
+		}
+	}
+
+	return results, err
+}
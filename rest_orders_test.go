@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testOrder = `[{"orderId":"order","figi":"FIGI","operation":"Buy","status":"New","requestedLots":3,"executedLots":0,"type":"Limit","price":10}]`
+
+func TestAwaitOrderStatusFromOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []string
+		status     OrderStatus
+		lots       int
+		err        error
+	}{
+		{
+			name:       "fill",
+			operations: []string{`{"operations":[{"id":"order","operationType":"Buy","status":"Done","figi":"FIGI","quantityExecuted":30}]}`},
+			status:     OrderStatusFill,
+			lots:       3,
+		},
+		{
+			name:       "partial fill",
+			operations: []string{`{"operations":[{"id":"order","operationType":"Buy","status":"Done","figi":"FIGI","quantityExecuted":10}]}`},
+			status:     OrderStatusCancelled,
+			lots:       1,
+		},
+		{
+			name: "operation is visible after poll",
+			operations: []string{
+				`{"operations":[]}`,
+				`{"operations":[{"id":"order","operationType":"Buy","status":"Done","figi":"FIGI","quantityExecuted":30}]}`,
+			},
+			status: OrderStatusFill,
+			lots:   3,
+		},
+		{
+			name:       "declined",
+			operations: []string{`{"operations":[{"id":"order","operationType":"Buy","status":"Decline","figi":"FIGI"}]}`},
+			status:     OrderStatusRejected,
+		},
+		{
+			name:       "no operation",
+			operations: []string{`{"operations":[]}`},
+			status:     OrderStatusNew,
+			err:        ErrOrderStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, p := newStubClient()
+			p.respond("/orders", testOrder, `[]`)
+			p.respond("/operations", tt.operations...)
+			p.respond("/market/search/by-figi", `{"figi":"FIGI","lot":10}`)
+
+			order, err := client.AwaitOrder(context.Background(), DefaultAccount, "order", time.Millisecond)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+			if order.Status != tt.status || order.ExecutedLots != tt.lots {
+				t.Fatalf("status %s with %d lots, want %s with %d lots", order.Status, order.ExecutedLots, tt.status, tt.lots)
+			}
+		})
+	}
+}
+
+func TestCancelOrdersContext(t *testing.T) {
+	client, p := newStubClient()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := client.CancelOrders(ctx, DefaultAccount, []string{"A", "B"})
+	if err == nil {
+		t.Fatal("active orders are fetched")
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Fatalf("result %+v", result)
+		}
+	}
+	if n := p.count("/orders/cancel"); n != 0 {
+		t.Fatalf("%d cancel requests with canceled ctx", n)
+	}
+}
+
+func TestOrderFills(t *testing.T) {
+	client, p := newStubClient()
+	p.respond("/operations", `{"operations":[
+		{"id":"order","operationType":"BrokerCommission","status":"Done","payment":-1,"currency":"RUB"},
+		{"id":"other","operationType":"Buy","status":"Done","figi":"FIGI","trades":[{"tradeId":"0","price":1,"quantity":1}]},
+		{"id":"order","operationType":"Sell","status":"Done","figi":"FIGI","quantity":30,"quantityExecuted":30,
+			"commission":{"currency":"RUB","value":-3},
+			"trades":[
+				{"tradeId":"1","date":"2020-01-10T10:00:00+03:00","price":100.5,"quantity":10},
+				{"tradeId":"2","date":"2020-01-10T10:00:01+03:00","price":100.25,"quantity":20}
+			]}
+	]}`)
+
+	fills, err := client.OrderFills(context.Background(), DefaultAccount, "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("fills %+v", fills)
+	}
+	if fills[0].Price != 100.5 || fills[0].Quantity != 10 || fills[0].Commission.Value != -1 || fills[0].Commission.Currency != RUB {
+		t.Fatalf("first fill %+v", fills[0])
+	}
+	if fills[1].Price != 100.25 || fills[1].Quantity != 20 || fills[1].Commission.Value != -2 {
+		t.Fatalf("second fill %+v", fills[1])
+	}
+	if !fills[1].Time.Equal(time.Date(2020, 1, 10, 7, 0, 1, 0, time.UTC)) {
+		t.Fatalf("fill time %s", fills[1].Time)
+	}
+
+	if _, err := client.OrderFills(context.Background(), DefaultAccount, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("fills of unknown order: %v", err)
+	}
+}
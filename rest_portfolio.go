@@ -0,0 +1,75 @@
+package sdk
+
+import "sort"
+
+// PortfolioSummary contains values and unrealized P&L of portfolio positions grouped by currency
+// of position price. Values of different currencies are never summed.
+type PortfolioSummary struct {
+	Positions []PositionSummary
+
+	values map[Currency]float64
+	pnl    map[Currency]float64
+}
+
+// PositionSummary contains current value and return of position.
+type PositionSummary struct {
+	PositionBalance
+	// Value is current value of position, average price multiplied by balance plus expected yield.
+	Value MoneyAmount
+	// ReturnPercent is expected yield in percents of position cost, 0 when cost is 0.
+	ReturnPercent float64
+}
+
+// Summary returns summary of portfolio positions, currency balances are not included.
+func (p Portfolio) Summary() PortfolioSummary {
+	summary := PortfolioSummary{
+		Positions: make([]PositionSummary, 0, len(p.Positions)),
+		values:    make(map[Currency]float64),
+		pnl:       make(map[Currency]float64),
+	}
+
+	for _, position := range p.Positions {
+		currency := position.AveragePositionPrice.Currency
+		cost := position.AveragePositionPrice.Value * position.Balance
+		value := cost + position.ExpectedYield.Value
+
+		var returnPercent float64
+		if cost != 0 {
+			returnPercent = position.ExpectedYield.Value / cost * 100
+		}
+
+		summary.Positions = append(summary.Positions, PositionSummary{
+			PositionBalance: position,
+			Value:           MoneyAmount{Currency: currency, Value: value},
+			ReturnPercent:   returnPercent,
+		})
+		summary.values[currency] += value
+		summary.pnl[currency] += position.ExpectedYield.Value
+	}
+
+	return summary
+}
+
+// Currencies returns sorted currencies of position prices.
+func (s PortfolioSummary) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(s.values))
+	for currency := range s.values {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i] < currencies[j]
+	})
+
+	return currencies
+}
+
+// TotalValue returns total value of positions priced in currency.
+func (s PortfolioSummary) TotalValue(currency Currency) MoneyAmount {
+	return MoneyAmount{Currency: currency, Value: s.values[currency]}
+}
+
+// UnrealizedPnL returns total expected yield of positions priced in currency, like TotalValue
+// expected yield is considered to be in currency of position price.
+func (s PortfolioSummary) UnrealizedPnL(currency Currency) MoneyAmount {
+	return MoneyAmount{Currency: currency, Value: s.pnl[currency]}
+}
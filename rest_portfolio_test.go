@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortfolioSummaryCurrencies(t *testing.T) {
+	portfolio := Portfolio{Positions: []PositionBalance{
+		{
+			FIGI:                 "RUB1",
+			Balance:              10,
+			AveragePositionPrice: MoneyAmount{Currency: RUB, Value: 100},
+			ExpectedYield:        MoneyAmount{Currency: RUB, Value: 50},
+		},
+		{
+			FIGI:                 "RUB2",
+			Balance:              1,
+			AveragePositionPrice: MoneyAmount{Currency: RUB, Value: 200},
+			ExpectedYield:        MoneyAmount{Currency: RUB, Value: -20},
+		},
+		{
+			// expected yield without currency is counted in currency of price
+			FIGI:                 "USD",
+			Balance:              2,
+			AveragePositionPrice: MoneyAmount{Currency: USD, Value: 30},
+			ExpectedYield:        MoneyAmount{Value: 6},
+		},
+	}}
+
+	summary := portfolio.Summary()
+	if got := summary.Currencies(); !reflect.DeepEqual(got, []Currency{RUB, USD}) {
+		t.Fatalf("currencies %v", got)
+	}
+
+	tests := []struct {
+		currency Currency
+		value    float64
+		pnl      float64
+	}{
+		{currency: RUB, value: 1230, pnl: 30},
+		{currency: USD, value: 66, pnl: 6},
+		{currency: EUR},
+	}
+	for _, tt := range tests {
+		if got := summary.TotalValue(tt.currency); got.Currency != tt.currency || got.Value != tt.value {
+			t.Errorf("value in %s %+v, want %v", tt.currency, got, tt.value)
+		}
+		if got := summary.UnrealizedPnL(tt.currency); got.Currency != tt.currency || got.Value != tt.pnl {
+			t.Errorf("pnl in %s %+v, want %v", tt.currency, got, tt.pnl)
+		}
+	}
+	if got := summary.Positions[2].ReturnPercent; got != 10 {
+		t.Errorf("return percent %v", got)
+	}
+}
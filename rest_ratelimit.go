@@ -0,0 +1,155 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var _ Provider = &rateLimitProvider{}
+
+// ErrRateLimited returned when api rate limit is exceeded, see RateLimitError.
+var ErrRateLimited = errors.New("rate limited")
+
+// MaxRateLimitRetries is max count of retries of request rejected by rate limit.
+const MaxRateLimitRetries = 3
+
+// DefaultRetryAfter is delay before retry of rate limited request without Retry-After header.
+const DefaultRetryAfter = time.Second
+
+// RateLimitError contains delay from Retry-After header of response with 429 status.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+// Error for implements error.
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+// Is for errors.Is with ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// WithRateLimit build rest client which paces requests to rpm requests per minute and
+// retries requests rejected by rate limit after Retry-After delay up to MaxRateLimitRetries times.
+func WithRateLimit(rpm int) BuildOption {
+	return func(client *RestClient) {
+		client.rateLimit = rpm
+	}
+}
+
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return DefaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return DefaultRetryAfter
+}
+
+// tokenBucket allows rpm requests per minute with bursts up to rpm requests.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(rpm int) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(rpm),
+		tokens:   float64(rpm),
+		rate:     float64(rpm) / 60,
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reserve takes token and returns 0 or returns delay until token is available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+type rateLimitProvider struct {
+	next   Provider
+	bucket *tokenBucket
+}
+
+// Get for implements Provider.
+func (p *rateLimitProvider) Get(ctx context.Context, url string, token string, unmarshal interface{}) error {
+	return p.do(ctx, func() error {
+		return p.next.Get(ctx, url, token, unmarshal)
+	})
+}
+
+// Post for implements Provider.
+func (p *rateLimitProvider) Post(ctx context.Context, url string, token string, payload, unmarshal interface{}) error {
+	return p.do(ctx, func() error {
+		return p.next.Post(ctx, url, token, payload, unmarshal)
+	})
+}
+
+func (p *rateLimitProvider) do(ctx context.Context, request func() error) error {
+	for retry := 0; ; retry++ {
+		if err := p.bucket.wait(ctx); err != nil {
+			return err
+		}
+
+		err := request()
+
+		var limitErr *RateLimitError
+		if !errors.As(err, &limitErr) || retry >= MaxRateLimitRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(limitErr.RetryAfter):
+		}
+	}
+}
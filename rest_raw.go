@@ -0,0 +1,87 @@
+package sdk
+
+import "encoding/json"
+
+// Raw returns original JSON of instrument. It allows to read fields not modeled by SDK yet.
+func (i Instrument) Raw() json.RawMessage {
+	return json.RawMessage(i.raw)
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (i *Instrument) UnmarshalJSON(data []byte) error {
+	type instrument Instrument
+
+	var v instrument
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exact struct {
+		MinPriceIncrement Decimal `json:"minPriceIncrement"`
+	}
+	if err := json.Unmarshal(data, &exact); err != nil {
+		return err
+	}
+
+	*i = Instrument(v)
+	i.raw = string(data)
+	i.exactMinPriceIncrement = exact.MinPriceIncrement
+
+	return nil
+}
+
+// Raw returns original JSON of order. It allows to read fields not modeled by SDK yet.
+func (o Order) Raw() json.RawMessage {
+	return json.RawMessage(o.raw)
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+
+	var v order
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exact struct {
+		Price Decimal `json:"price"`
+	}
+	if err := json.Unmarshal(data, &exact); err != nil {
+		return err
+	}
+
+	*o = Order(v)
+	o.raw = string(data)
+	o.exactPrice = exact.Price
+
+	return nil
+}
+
+// Raw returns original JSON of operation. It allows to read fields not modeled by SDK yet.
+func (o Operation) Raw() json.RawMessage {
+	return json.RawMessage(o.raw)
+}
+
+// UnmarshalJSON for implements json.Unmarshaler.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+
+	var v operation
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var exact struct {
+		Price Decimal `json:"price"`
+	}
+	if err := json.Unmarshal(data, &exact); err != nil {
+		return err
+	}
+
+	*o = Operation(v)
+	o.raw = string(data)
+	o.exactPrice = exact.Price
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+var _ Provider = &retryProvider{}
+
+// RetryConfig configures retries of rest client requests on 5xx responses and network errors.
+// Other errors, e.g. 4xx responses including 429 and decode errors, are returned immediately.
+type RetryConfig struct {
+	// MaxAttempts is max count of attempts including first request.
+	MaxAttempts int
+	// InitialBackoff is delay before first retry, it is doubled for each next retry.
+	InitialBackoff time.Duration
+	// MaxBackoff limits delay between retries.
+	MaxBackoff time.Duration
+	// RetryPost enables retries of POST requests. API has no idempotency keys,
+	// so retried order placement can place order twice. Use with care.
+	RetryPost bool
+}
+
+// WithRetry build rest client with retries of failed requests.
+func WithRetry(cfg RetryConfig) BuildOption {
+	return func(client *RestClient) {
+		client.retry = &cfg
+	}
+}
+
+type retryProvider struct {
+	next Provider
+	cfg  RetryConfig
+}
+
+// Get for implements Provider.
+func (p *retryProvider) Get(ctx context.Context, url string, token string, unmarshal interface{}) error {
+	return p.do(ctx, func() error {
+		return p.next.Get(ctx, url, token, unmarshal)
+	})
+}
+
+// Post for implements Provider.
+func (p *retryProvider) Post(ctx context.Context, url string, token string, payload, unmarshal interface{}) error {
+	if !p.cfg.RetryPost {
+		return p.next.Post(ctx, url, token, payload, unmarshal)
+	}
+
+	return p.do(ctx, func() error {
+		return p.next.Post(ctx, url, token, payload, unmarshal)
+	})
+}
+
+func (p *retryProvider) do(ctx context.Context, request func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = request()
+		if err == nil || attempt >= p.cfg.MaxAttempts || !isRetryable(ctx, err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns exponential delay with jitter in [delay/2, delay].
+func (p *retryProvider) backoff(attempt int) time.Duration {
+	delay := p.cfg.InitialBackoff
+	for i := 1; i < attempt && (p.cfg.MaxBackoff <= 0 || delay < p.cfg.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.cfg.MaxBackoff > 0 && delay > p.cfg.MaxBackoff {
+		delay = p.cfg.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNotFound) {
+		return false
+	}
+
+	var networkError *NetworkError
+	if errors.As(err, &networkError) {
+		return true
+	}
+
+	// other errors, e.g. RateLimitError and decode errors, aren't retried
+	var tradingError TradingError
+
+	return errors.As(err, &tradingError) && tradingError.HTTPStatus >= http.StatusInternalServerError
+}
@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		attempts int32
+	}{
+		{
+			name:     "server error",
+			handler:  func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			attempts: 3,
+		},
+		{
+			name:     "client error",
+			handler:  func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadRequest) },
+			attempts: 1,
+		},
+		{
+			name:     "rate limit",
+			handler:  func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTooManyRequests) },
+			attempts: 1,
+		},
+		{
+			name:     "decode error",
+			handler:  func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(`{"payload":`)) },
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			client := NewRestClient("token", WithURL(srv.URL), WithRetry(RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+			if _, err := client.Orders(context.Background(), DefaultAccount); err == nil {
+				t.Fatal("no error")
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Fatalf("%d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+
+	t.Run("network error", func(t *testing.T) {
+		var attempts int32
+		client := NewRestClient("token",
+			WithURL("http://127.0.0.1:1"),
+			WithRetry(RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+			WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&attempts, 1)
+				return http.DefaultTransport.RoundTrip(r)
+			})}),
+		)
+		if _, err := client.Orders(context.Background(), DefaultAccount); err == nil {
+			t.Fatal("no error")
+		}
+		if got := atomic.LoadInt32(&attempts); got != 3 {
+			t.Fatalf("%d attempts, want 3", got)
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
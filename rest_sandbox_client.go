@@ -10,9 +10,15 @@ type SandboxRestClient struct {
 	*RestClient
 }
 
+// SandboxRestAPIURL contains sandbox api url for tinkoff invest api.
+const SandboxRestAPIURL = RestAPIURL + "/sandbox"
+
 // NewSandboxRestClient returns new SandboxRestClient by token.
-func NewSandboxRestClient(token string) *SandboxRestClient {
-	return &SandboxRestClient{RestClient: NewRestClient(token, WithURL(RestAPIURL+"/sandbox"))}
+// Orders and portfolio methods of embedded RestClient work with sandbox accounts.
+func NewSandboxRestClient(token string, options ...BuildOption) *SandboxRestClient {
+	options = append([]BuildOption{WithURL(SandboxRestAPIURL)}, options...)
+
+	return &SandboxRestClient{RestClient: NewRestClient(token, options...)}
 }
 
 // NewSandboxRestClientCustom returns new custom SandboxRestClient by token and api url.
@@ -32,7 +38,7 @@ func (c *SandboxRestClient) Register(ctx context.Context, accountType AccountTyp
 		AccountType AccountType `json:"brokerAccountType"`
 	}{AccountType: accountType}
 
-	err := c.provider.Post(ctx, path, c.token, payload, &response)
+	err := c.provider.Post(ctx, path, c.getToken(), payload, &response)
 	if err != nil {
 		return Account{}, fmt.Errorf("provider post: %w", err)
 	}
@@ -48,7 +54,7 @@ func (c *SandboxRestClient) Clear(ctx context.Context, accountID string) error {
 		path += "?brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Post(ctx, path, c.token, nil, nil)
+	err := c.provider.Post(ctx, path, c.getToken(), nil, nil)
 	if err != nil {
 		return fmt.Errorf("provider post: %w", err)
 	}
@@ -64,7 +70,7 @@ func (c *SandboxRestClient) Remove(ctx context.Context, accountID string) error
 		path += "?brokerAccountId=" + accountID
 	}
 
-	err := c.provider.Post(ctx, path, c.token, nil, nil)
+	err := c.provider.Post(ctx, path, c.getToken(), nil, nil)
 	if err != nil {
 		return fmt.Errorf("provider post: %w", err)
 	}
@@ -86,7 +92,7 @@ func (c *SandboxRestClient) SetCurrencyBalance(ctx context.Context, accountID st
 		payload.AccountID = accountID
 	}
 
-	err := c.provider.Post(ctx, path, c.token, payload, nil)
+	err := c.provider.Post(ctx, path, c.getToken(), payload, nil)
 	if err != nil {
 		return fmt.Errorf("provider post: %w", err)
 	}
@@ -94,6 +100,11 @@ func (c *SandboxRestClient) SetCurrencyBalance(ctx context.Context, accountID st
 	return nil
 }
 
+// SetPositionBalance is an alias for SetPositionsBalance.
+func (c *SandboxRestClient) SetPositionBalance(ctx context.Context, accountID, figi string, balance float64) error {
+	return c.SetPositionsBalance(ctx, accountID, figi, balance)
+}
+
 // SetPositionsBalance see docs https://tinkoffcreditsystems.github.io/invest-openapi/swagger-ui/#/sandbox/post_sandbox_positions_balance.
 func (c *SandboxRestClient) SetPositionsBalance(ctx context.Context, accountID, figi string, balance float64) error {
 	path := c.url + "/sandbox/positions/balance"
@@ -108,7 +119,7 @@ func (c *SandboxRestClient) SetPositionsBalance(ctx context.Context, accountID,
 		payload.AccountID = accountID
 	}
 
-	err := c.provider.Post(ctx, path, c.token, payload, nil)
+	err := c.provider.Post(ctx, path, c.getToken(), payload, nil)
 	if err != nil {
 		return fmt.Errorf("provider post: %w", err)
 	}
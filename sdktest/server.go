@@ -0,0 +1,188 @@
+// Package sdktest provides in-memory streaming api server for tests of code using sdk.StreamingClient.
+package sdktest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrNoConnections returned by Send when no client is connected.
+var ErrNoConnections = errors.New("no connected clients")
+
+// RequestsBufferSize is count of received requests buffered by server.
+const RequestsBufferSize = 1000
+
+// Request is subscribe or unsubscribe message received from client.
+type Request struct {
+	Event     string `json:"event"`
+	RequestID string `json:"request_id"`
+	FIGI      string `json:"figi"`
+	Interval  string `json:"interval,omitempty"`
+	Depth     int    `json:"depth,omitempty"`
+}
+
+// Server is streaming api server listening on local address. Use URL with sdk.NewStreamingClientCustom.
+type Server struct {
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+	requests chan Request
+
+	mu         sync.Mutex
+	conns      map[*websocket.Conn]struct{}
+	headers    []http.Header
+	rejectWith int
+}
+
+// NewServer starts new server, it has to be closed by Close.
+func NewServer() *Server {
+	s := &Server{
+		requests: make(chan Request, RequestsBufferSize),
+		conns:    make(map[*websocket.Conn]struct{}),
+	}
+	// compression is used only by clients requesting it
+	s.upgrader.EnableCompression = true
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// URL returns websocket url of server.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// Close disconnects all clients and stops server.
+func (s *Server) Close() {
+	s.DropConnections()
+	s.srv.Close()
+}
+
+// Send writes event as JSON to all connected clients, e.g. sdk.CandleEvent or raw map.
+func (s *Server) Send(event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.SendRaw(data)
+}
+
+// SendRaw writes message to all connected clients.
+func (s *Server) SendRaw(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.conns) == 0 {
+		return ErrNoConnections
+	}
+
+	for conn := range s.conns {
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Requests returns channel of messages received from clients.
+func (s *Server) Requests() <-chan Request {
+	return s.requests
+}
+
+// NextRequest waits for next message received from clients.
+func (s *Server) NextRequest(ctx context.Context) (Request, error) {
+	select {
+	case <-ctx.Done():
+		return Request{}, ctx.Err()
+	case r := <-s.requests:
+		return r, nil
+	}
+}
+
+// Connections returns count of connected clients.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+// Headers returns handshake headers of all connections in order, e.g. to check Authorization.
+func (s *Server) Headers() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]http.Header(nil), s.headers...)
+}
+
+// DropConnections closes all client connections without close frame, as lost network does.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// RejectWith makes server respond to next handshakes with status, e.g. http.StatusForbidden.
+// Zero status accepts handshakes again.
+func (s *Server) RejectWith(status int) {
+	s.mu.Lock()
+	s.rejectWith = status
+	s.mu.Unlock()
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.headers = append(s.headers, r.Header.Clone())
+	status := s.rejectWith
+	s.mu.Unlock()
+
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal(msg, &req); err != nil {
+			continue
+		}
+
+		select {
+		case s.requests <- req:
+		default:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sdktest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	if err := srv.SendRaw([]byte(`{}`)); err != ErrNoConnections {
+		t.Fatalf("send without connections: %v", err)
+	}
+
+	header := http.Header{"Authorization": []string{"Bearer token"}}
+	conn, _, err := websocket.DefaultDialer.Dial(srv.URL(), header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(Request{Event: "candle:subscribe", FIGI: "FIGI", Interval: "1min"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := srv.NextRequest(ctx)
+	if err != nil || req.Event != "candle:subscribe" || req.FIGI != "FIGI" || req.Interval != "1min" {
+		t.Fatalf("request %+v, %v", req, err)
+	}
+	if got := srv.Headers()[0].Get("Authorization"); got != "Bearer token" {
+		t.Fatalf("authorization %q", got)
+	}
+	if n := srv.Connections(); n != 1 {
+		t.Fatalf("%d connections", n)
+	}
+
+	if err := srv.Send(map[string]string{"event": "candle"}); err != nil {
+		t.Fatal(err)
+	}
+	var event map[string]string
+	if err := conn.ReadJSON(&event); err != nil || event["event"] != "candle" {
+		t.Fatalf("event %v, %v", event, err)
+	}
+
+	srv.DropConnections()
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Fatal("connection isn't dropped")
+	}
+
+	srv.RejectWith(http.StatusForbidden)
+	if _, resp, err := websocket.DefaultDialer.Dial(srv.URL(), nil); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("handshake isn't rejected: %v", err)
+	}
+	srv.RejectWith(0)
+	conn, _, err = websocket.DefaultDialer.Dial(srv.URL(), nil)
+	if err != nil {
+		t.Fatalf("handshake after reject: %v", err)
+	}
+	conn.Close()
+}
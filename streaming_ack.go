@@ -0,0 +1,210 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SubscriptionError contains error sent by server for subscription request.
+// It unwraps to *StreamError, see ErrorEvent.Err.
+type SubscriptionError struct {
+	RequestID string
+	Message   string
+
+	err error
+}
+
+// Error for implements error.
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("subscription %s failed: %s", e.RequestID, e.Message)
+}
+
+// Unwrap returns *StreamError of error event.
+func (e *SubscriptionError) Unwrap() error {
+	return e.err
+}
+
+type eventWaiter struct {
+	params SubscriptionParams
+	ch     chan StreamEvent
+}
+
+// SubscribeOrderbookSync subscribes to orderbook and waits until subscription takes effect.
+// Streaming api doesn't confirm subscriptions, so first orderbook event of subscription is
+// treated as confirmation, error event with requestID is returned as *SubscriptionError.
+// Events are read by read loop, so RunReadLoop should be running.
+func (c *StreamingClient) SubscribeOrderbookSync(ctx context.Context, figi string, depth int, requestID string) error {
+	// id is generated here, so error event of subscription can be matched
+	requestID = c.requestID(requestID)
+	w := c.addWaiter(SubscriptionParams{Kind: KindOrderBook, FIGI: figi, Depth: depth, RequestID: requestID})
+	defer c.removeWaiter(w)
+
+	if err := c.SubscribeOrderbook(figi, depth, requestID); err != nil {
+		return err
+	}
+
+	_, err := c.wait(ctx, w)
+
+	return err
+}
+
+// SubscribeCandleAck subscribes to candles and waits until subscription takes effect, see SubscribeOrderbookSync.
+func (c *StreamingClient) SubscribeCandleAck(ctx context.Context, figi string, interval CandleInterval, requestID string) error {
+	// id is generated here, so error event of subscription can be matched
+	requestID = c.requestID(requestID)
+	w := c.addWaiter(SubscriptionParams{Kind: KindCandle, FIGI: figi, Interval: interval, RequestID: requestID})
+	defer c.removeWaiter(w)
+
+	if err := c.SubscribeCandle(figi, interval, requestID); err != nil {
+		return err
+	}
+
+	_, err := c.wait(ctx, w)
+
+	return err
+}
+
+// SubscribeInstrumentInfoAck subscribes to instrument info and waits until subscription takes effect,
+// see SubscribeOrderbookSync. Instrument info is sent on change only, so use ctx with deadline.
+func (c *StreamingClient) SubscribeInstrumentInfoAck(ctx context.Context, figi string, requestID string) error {
+	// id is generated here, so error event of subscription can be matched
+	requestID = c.requestID(requestID)
+	w := c.addWaiter(SubscriptionParams{Kind: KindInstrumentInfo, FIGI: figi, RequestID: requestID})
+	defer c.removeWaiter(w)
+
+	if err := c.SubscribeInstrumentInfo(figi, requestID); err != nil {
+		return err
+	}
+
+	_, err := c.wait(ctx, w)
+
+	return err
+}
+
+// ErrRequestID returned by WaitFor and Expect for empty request id.
+var ErrRequestID = errors.New("empty request id")
+
+// Waiter is one-shot waiter of event with request id, see Expect.
+type Waiter struct {
+	client *StreamingClient
+	w      *eventWaiter
+	once   sync.Once
+}
+
+// Expect registers waiter of request before request is sent, so event received right after
+// subscribe isn't missed, e.g.:
+//
+//	w, _ := client.Expect(requestID)
+//	defer w.Cancel()
+//	client.SubscribeCandle(figi, interval, requestID)
+//	event, err := w.Wait(ctx)
+//
+// Waiter gets first event of subscription with requestID or error event with requestID,
+// see WaitFor. Events are read by read loop, so RunReadLoop should be running.
+func (c *StreamingClient) Expect(requestID string) (*Waiter, error) {
+	if requestID == "" {
+		return nil, ErrRequestID
+	}
+
+	params := SubscriptionParams{RequestID: requestID}
+	if active, ok := c.subscriptionByRequest(requestID); ok {
+		params = active
+	}
+
+	return &Waiter{client: c, w: c.addWaiter(params)}, nil
+}
+
+// Wait waits for event until ctx is done, error event is returned with *SubscriptionError.
+// Waiter is cancelled after Wait.
+func (w *Waiter) Wait(ctx context.Context) (interface{}, error) {
+	defer w.Cancel()
+
+	return w.client.wait(ctx, w.w)
+}
+
+// Cancel removes waiter, it is safe to call Cancel several times.
+func (w *Waiter) Cancel() {
+	w.once.Do(func() {
+		w.client.removeWaiter(w.w)
+	})
+}
+
+// WaitFor waits for first event of active subscription with requestID or error event with requestID,
+// error event is returned with *SubscriptionError. Only error events carry request id, so for unknown
+// requestID only error is waited. Event received before WaitFor is missed, use Expect to register
+// waiter before subscribe. Events are read by read loop, so RunReadLoop should be running.
+func (c *StreamingClient) WaitFor(ctx context.Context, requestID string) (interface{}, error) {
+	w, err := c.Expect(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Wait(ctx)
+}
+
+func (c *StreamingClient) wait(ctx context.Context, w *eventWaiter) (StreamEvent, error) {
+	select {
+	case event := <-w.ch:
+		if e, ok := event.(ErrorEvent); ok {
+			return event, &SubscriptionError{RequestID: e.Error.RequestID, Message: e.Error.Error, err: e.Err()}
+		}
+
+		return event, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *StreamingClient) addWaiter(params SubscriptionParams) *eventWaiter {
+	w := &eventWaiter{params: params, ch: make(chan StreamEvent, 1)}
+
+	c.waitersMu.Lock()
+	c.waiters[w] = struct{}{}
+	c.waitersMu.Unlock()
+
+	return w
+}
+
+func (c *StreamingClient) removeWaiter(w *eventWaiter) {
+	c.waitersMu.Lock()
+	delete(c.waiters, w)
+	c.waitersMu.Unlock()
+}
+
+func (c *StreamingClient) dispatchWaiters(event StreamEvent) {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+
+	for w := range c.waiters {
+		// waiter registered by Expect before subscribe gets params of subscription when it is active
+		if w.params.Kind == KindUnknown && event.Kind() != KindError {
+			if active, ok := c.subscriptionByRequest(w.params.RequestID); ok {
+				w.params = active
+			}
+		}
+		if !w.params.matches(event) {
+			continue
+		}
+
+		select {
+		case w.ch <- event:
+		default:
+		}
+	}
+}
+
+func (c *StreamingClient) subscriptionByRequest(requestID string) (SubscriptionParams, bool) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for _, params := range c.subs {
+		if params.RequestID == requestID {
+			return params, true
+		}
+	}
+
+	return SubscriptionParams{}, false
+}
@@ -0,0 +1,170 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func TestExpect(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	received := make(chan interface{}, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		received <- event
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	failed, err := client.Expect("failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeCandle("UNKNOWN", CandleInterval1Min, "failed"); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+	if err := srv.Send(map[string]interface{}{
+		"event":   "error",
+		"payload": map[string]interface{}{"request_id": "failed", "error": "unknown figi"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// error event is dispatched before Wait is called
+	<-received
+	var subErr *SubscriptionError
+	if _, err := failed.Wait(ctx); !errors.As(err, &subErr) || subErr.RequestID != "failed" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ok, err := client.Expect("ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeCandle("FIGI", CandleInterval1Min, "ok"); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+	sendCandles(t, srv, 1)
+	event, err := ok.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if candle, isCandle := event.(CandleEvent); !isCandle || candle.Candle.FIGI != "FIGI" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	if _, err := client.WaitFor(ctx, ""); err != ErrRequestID {
+		t.Fatalf("wait for empty request id: %v", err)
+	}
+	if _, err := client.Expect(""); err != ErrRequestID {
+		t.Fatalf("expect empty request id: %v", err)
+	}
+
+	client.waitersMu.Lock()
+	defer client.waitersMu.Unlock()
+	if len(client.waiters) != 0 {
+		t.Fatalf("%d waiters are not removed", len(client.waiters))
+	}
+}
+
+// testAck checks subscribe waiting for first event: it succeeds on event sent by confirm,
+// fails with *SubscriptionError on error event with request id and with ctx error on timeout.
+func testAck(t *testing.T, subscribe func(client *StreamingClient, ctx context.Context, requestID string) error, confirm func(srv *sdktest.Server) error) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	runReadLoop(client)
+
+	ack := func(ctx context.Context, requestID string) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- subscribe(client, ctx, requestID)
+		}()
+		if req := nextRequest(t, srv); req.RequestID != requestID {
+			t.Fatalf("unexpected request %+v", req)
+		}
+
+		return errc
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	errc := ack(ctx, "ok")
+	if err := confirm(srv); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitErr(t, errc); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if err := client.UnsubscribeAll(); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+
+	errc = ack(ctx, "failed")
+	if err := srv.Send(map[string]interface{}{
+		"event":   "error",
+		"payload": map[string]interface{}{"request_id": "failed", "error": "Subscription failed. FIGI FIGI not found"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err := waitErr(t, errc)
+	var subErr *SubscriptionError
+	if !errors.As(err, &subErr) || subErr.RequestID != "failed" || !strings.Contains(subErr.Message, "not found") {
+		t.Fatalf("ack of failed subscription: %v", err)
+	}
+	var streamErr *StreamError
+	if !errors.As(errors.Unwrap(subErr), &streamErr) || streamErr.RequestID != "failed" {
+		t.Fatalf("subscription error %v doesn't unwrap to *StreamError", subErr)
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer timeoutCancel()
+	if err := waitErr(t, ack(timeoutCtx, "timeout")); err != context.DeadlineExceeded {
+		t.Fatalf("ack without events: %v", err)
+	}
+}
+
+func TestSubscribeOrderbookSync(t *testing.T) {
+	testAck(t,
+		func(client *StreamingClient, ctx context.Context, requestID string) error {
+			return client.SubscribeOrderbookSync(ctx, "FIGI", 10, requestID)
+		},
+		func(srv *sdktest.Server) error {
+			return srv.SendRaw([]byte(`{"event":"orderbook","payload":{"figi":"FIGI","depth":10,"bids":[[10,1]],"asks":[[11,1]]}}`))
+		},
+	)
+}
+
+func TestSubscribeCandleAck(t *testing.T) {
+	testAck(t,
+		func(client *StreamingClient, ctx context.Context, requestID string) error {
+			return client.SubscribeCandleAck(ctx, "FIGI", CandleInterval1Min, requestID)
+		},
+		func(srv *sdktest.Server) error {
+			return srv.SendRaw([]byte(`{"event":"candle","payload":{"figi":"FIGI","interval":"1min","c":1}}`))
+		},
+	)
+}
+
+func TestSubscribeInstrumentInfoAck(t *testing.T) {
+	testAck(t,
+		func(client *StreamingClient, ctx context.Context, requestID string) error {
+			return client.SubscribeInstrumentInfoAck(ctx, "FIGI", requestID)
+		},
+		func(srv *sdktest.Server) error {
+			return srv.SendRaw([]byte(`{"event":"instrument_info","payload":{"figi":"FIGI","trade_status":"normal_trading"}}`))
+		},
+	)
+}
@@ -0,0 +1,135 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// BatchError is returned by batch subscribe methods, Index is index of failed figi.
+// Subscriptions before Index are done, so subscribing can be resumed from Index.
+//
+// Each subscription of batch gets own request id: non-empty requestID of several subscriptions gets
+// index suffix, e.g. "id-0", "id-1", empty one is generated. So error event of single figi ends only
+// its subscription. Already active subscription keeps its request id. Ids are returned by
+// SubscribeCandlesIDs and others.
+type BatchError struct {
+	Index int
+	FIGI  string
+	Err   error
+}
+
+// Error for implements error.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch failed at %d (%s): %v", e.Index, e.FIGI, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// SubscribeCandles subscribes to candles of several figis, see SubscribeCandle.
+// Non-empty requestID of several figis gets index suffix, see BatchError, use SubscribeCandlesIDs
+// to get request ids. On failure it returns *BatchError.
+func (c *StreamingClient) SubscribeCandles(figis []string, interval CandleInterval, requestID string) error {
+	_, err := c.SubscribeCandlesIDs(figis, interval, requestID)
+
+	return err
+}
+
+// SubscribeCandlesIDs subscribes like SubscribeCandles and returns request ids of subscriptions in figis order,
+// on failure ids of subscriptions done before failed one are returned.
+func (c *StreamingClient) SubscribeCandlesIDs(figis []string, interval CandleInterval, requestID string) ([]string, error) {
+	if !IsValidCandleInterval(interval) {
+		return nil, ErrInterval
+	}
+
+	params := make([]SubscriptionParams, 0, len(figis))
+	for _, figi := range figis {
+		params = append(params, SubscriptionParams{Kind: KindCandle, FIGI: figi, Interval: interval, RequestID: requestID})
+	}
+
+	return c.subscribeBatch(params)
+}
+
+// SubscribeCandleIntervals subscribes to candles of figi with several intervals, see SubscribeCandle.
+// Each interval is separate subscription, so it can be unsubscribed by UnsubscribeCandle separately.
+// Intervals are validated before sending, invalid one returns ErrInterval and nothing is sent.
+// Subscribing stops on first failed write, because connection can't be used after it, and *BatchError
+// with Index of failed interval is returned, intervals before Index are subscribed.
+func (c *StreamingClient) SubscribeCandleIntervals(figi string, intervals []CandleInterval, requestID string) error {
+	params := make([]SubscriptionParams, 0, len(intervals))
+	for _, interval := range intervals {
+		if !IsValidCandleInterval(interval) {
+			return ErrInterval
+		}
+		params = append(params, SubscriptionParams{Kind: KindCandle, FIGI: figi, Interval: interval, RequestID: requestID})
+	}
+
+	_, err := c.subscribeBatch(params)
+
+	return err
+}
+
+// SubscribeOrderbooks subscribes to orderbooks of several figis, see SubscribeOrderbook.
+// Non-empty requestID of several figis gets index suffix, see BatchError, use SubscribeOrderbooksIDs
+// to get request ids. On failure it returns *BatchError.
+func (c *StreamingClient) SubscribeOrderbooks(figis []string, depth int, requestID string) error {
+	_, err := c.SubscribeOrderbooksIDs(figis, depth, requestID)
+
+	return err
+}
+
+// SubscribeOrderbooksIDs subscribes like SubscribeOrderbooks and returns request ids, see SubscribeCandlesIDs.
+func (c *StreamingClient) SubscribeOrderbooksIDs(figis []string, depth int, requestID string) ([]string, error) {
+	if depth < 1 || depth > MaxOrderbookDepth {
+		return nil, ErrDepth
+	}
+
+	params := make([]SubscriptionParams, 0, len(figis))
+	for _, figi := range figis {
+		params = append(params, SubscriptionParams{Kind: KindOrderBook, FIGI: figi, Depth: depth, RequestID: requestID})
+	}
+
+	return c.subscribeBatch(params)
+}
+
+// SubscribeInstrumentInfos subscribes to instrument info of several figis, see SubscribeInstrumentInfo.
+// Non-empty requestID of several figis gets index suffix, see BatchError, use SubscribeInstrumentInfosIDs
+// to get request ids. On failure it returns *BatchError.
+func (c *StreamingClient) SubscribeInstrumentInfos(figis []string, requestID string) error {
+	_, err := c.SubscribeInstrumentInfosIDs(figis, requestID)
+
+	return err
+}
+
+// SubscribeInstrumentInfosIDs subscribes like SubscribeInstrumentInfos and returns request ids, see SubscribeCandlesIDs.
+func (c *StreamingClient) SubscribeInstrumentInfosIDs(figis []string, requestID string) ([]string, error) {
+	params := make([]SubscriptionParams, 0, len(figis))
+	for _, figi := range figis {
+		params = append(params, SubscriptionParams{Kind: KindInstrumentInfo, FIGI: figi, RequestID: requestID})
+	}
+
+	return c.subscribeBatch(params)
+}
+
+// subscribeBatch writes all subscriptions holding write lock once and returns their active request ids.
+// Streaming api has no batch message, so subscriptions are sent one by one.
+func (c *StreamingClient) subscribeBatch(params []SubscriptionParams) ([]string, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	ids := make([]string, 0, len(params))
+	for i := range params {
+		if params[i].RequestID != "" && len(params) > 1 {
+			params[i].RequestID += "-" + strconv.Itoa(i)
+		}
+		id, err := c.subscribeLocked(params[i])
+		if err != nil {
+			return ids, &BatchError{Index: i, FIGI: params[i].FIGI, Err: err}
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+// BenchmarkSubscribeInstruments subscribes to 1000 instruments and unsubscribes from them
+// with default and custom write buffer sizes.
+func BenchmarkSubscribeInstruments(b *testing.B) {
+	figis := make([]string, 1000)
+	for i := range figis {
+		figis[i] = "BBG" + strconv.Itoa(1000000000+i)
+	}
+
+	for _, size := range []int{0, 256, 64 << 10} {
+		b.Run(fmt.Sprintf("write_buffer=%d", size), func(b *testing.B) {
+			srv := sdktest.NewServer()
+			defer srv.Close()
+
+			var options []StreamingOption
+			if size > 0 {
+				options = append(options, WithWriteBufferSize(size))
+			}
+			client := newTestClient(b, srv, options...)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := client.SubscribeInstrumentInfos(figis, ""); err != nil {
+					b.Fatal(err)
+				}
+				if err := client.UnsubscribeAll(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscribeCandleIntervals(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	if err := client.SubscribeCandleIntervals("FIGI", []CandleInterval{CandleInterval1Min, "7min"}, ""); err != ErrInterval {
+		t.Fatalf("subscribe with invalid interval: %v", err)
+	}
+	noRequest(t, srv)
+
+	intervals := []CandleInterval{CandleInterval1Min, CandleInterval5Min, CandleInterval1Hour}
+	if err := client.SubscribeCandleIntervals("FIGI", intervals, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, interval := range intervals {
+		if req := nextRequest(t, srv); req.Event != "candle:subscribe" || req.Interval != string(interval) {
+			t.Fatalf("unexpected request %+v, want interval %s", req, interval)
+		}
+	}
+	if active := client.ActiveSubscriptions(); len(active) != len(intervals) {
+		t.Fatalf("active subscriptions %+v", active)
+	}
+
+	if err := client.UnsubscribeCandle("FIGI", CandleInterval5Min, ""); err != nil {
+		t.Fatal(err)
+	}
+	if req := nextRequest(t, srv); req.Event != "candle:unsubscribe" || req.Interval != string(CandleInterval5Min) {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	active := client.ActiveSubscriptions()
+	if len(active) != 2 || active[0].Interval == CandleInterval5Min || active[1].Interval == CandleInterval5Min {
+		t.Fatalf("active subscriptions %+v after unsubscribe", active)
+	}
+}
+
+func TestBatchRequestIDs(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	if err := client.SubscribeOrderbook("B", 10, "single"); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+
+	ids, err := client.SubscribeOrderbooksIDs([]string{"A", "B", "C"}, 10, "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// B is already active with its own request id
+	if want := []string{"batch-0", "single", "batch-2"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("request ids %v, want %v", ids, want)
+	}
+	for _, want := range []string{"batch-0", "batch-2"} {
+		if req := nextRequest(t, srv); req.RequestID != want {
+			t.Fatalf("request %+v, want id %s", req, want)
+		}
+	}
+
+	ids, err = client.SubscribeInstrumentInfosIDs([]string{"D"}, "info")
+	if err != nil || !reflect.DeepEqual(ids, []string{"info"}) {
+		t.Fatalf("request ids of single figi %v: %v", ids, err)
+	}
+	ids, err = client.SubscribeCandlesIDs([]string{"E", "F"}, CandleInterval1Min, "")
+	if err != nil || len(ids) != 2 || ids[0] == "" || ids[0] == ids[1] {
+		t.Fatalf("generated request ids %v: %v", ids, err)
+	}
+}
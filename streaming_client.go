@@ -1,10 +1,15 @@
 package sdk
 
 import (
+	"compress/flate"
+	"context"
 	"encoding/json"
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -15,145 +20,809 @@ const StreamingApiURL = "wss://invest-public-api.tinkoff.ru/openapi/md/v1/md-ope
 
 const DefaultPongWait = 60 * time.Second
 const DefaultPingPeriod = 54 * time.Second
+const DefaultWriteWait = 5 * time.Second
 
+const closeWait = time.Second
+
+// DefaultReadLimit is default max size of received message in bytes, zero is no limit.
+const DefaultReadLimit = 0
+
+// Logger is used by streaming client for logging. When it implements LeveledLogger
+// too, leveled methods are used instead of Printf.
 type Logger interface {
 	Printf(format string, args ...interface{})
 }
 
+// PingPongConfig configures websocket ping/pong keepalive, see NewPingPongConfig.
 type PingPongConfig struct {
 	isEnabled  bool
 	pongWait   time.Duration
 	pingPeriod time.Duration
 }
 
+// NewPingPongConfig returns enabled ping/pong config: ping is sent every pingPeriod and connection
+// is stale when no pong is received for pongWait. pingPeriod should be positive and less than pongWait,
+// otherwise streaming client constructor returns ErrPingPong.
+func NewPingPongConfig(pongWait, pingPeriod time.Duration) *PingPongConfig {
+	return &PingPongConfig{isEnabled: true, pongWait: pongWait, pingPeriod: pingPeriod}
+}
+
+func (cfg *PingPongConfig) validate() error {
+	if cfg == nil || !cfg.isEnabled {
+		return nil
+	}
+	if cfg.pingPeriod <= 0 || cfg.pingPeriod >= cfg.pongWait {
+		return ErrPingPong
+	}
+
+	return nil
+}
+
+// WithPingPong enables ping/pong keepalive, see NewPingPongConfig.
+func WithPingPong(pongWait, pingPeriod time.Duration) StreamingOption {
+	return func(client *StreamingClient) {
+		client.pingPongCfg = NewPingPongConfig(pongWait, pingPeriod)
+	}
+}
+
+// WithOnStale sets callback called when pong isn't received in time and connection is considered stale.
+// It is called from read loop before reconnect.
+func WithOnStale(fn func()) StreamingOption {
+	return func(client *StreamingClient) {
+		client.onStale = fn
+	}
+}
+
+// Decoder decodes messages of streaming api. Default decoder uses encoding/json.
+type Decoder interface {
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// DecoderFunc is adapter of unmarshal function to Decoder.
+type DecoderFunc func(data []byte, v interface{}) error
+
+// Unmarshal for implements Decoder.
+func (f DecoderFunc) Unmarshal(data []byte, v interface{}) error {
+	return f(data, v)
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// CallbackErrorPolicy defines behavior of read loop when callback returns error.
+type CallbackErrorPolicy int
+
+const (
+	// CallbackErrorStop stops read loop and returns callback error. It is default policy.
+	CallbackErrorStop CallbackErrorPolicy = iota
+	// CallbackErrorContinue skips event and continues read loop.
+	CallbackErrorContinue
+	// CallbackErrorContinueAndLog logs callback error and continues read loop.
+	CallbackErrorContinueAndLog
+)
+
+// StreamingClient is safe for concurrent use, all writes to connection are serialized.
 type StreamingClient struct {
+	// 64-bit fields below are accessed atomically, they are first to be 64-bit aligned.
+	requestIDCounter uint64
+	lastMessageAt    int64
+	droppedEvents    uint64
+	lastRTT          int64
+	connectedAt      int64
+
 	logger Logger
+	name   string
 	conn   *websocket.Conn
-	token  string
 	apiURL string
 
+	tokenMu sync.RWMutex
+	token   string
+
 	pingPongCfg *PingPongConfig
+	pingMu      sync.Mutex
 	pingTicker  *time.Ticker
+	pingStop    chan struct{}
+
+	decoder             Decoder
+	eventFilter         func(name string) bool
+	rawMessageHandler   func(messageType int, data []byte)
+	unknownEventHandler func(name string, raw []byte)
+	callbackErrorPolicy CallbackErrorPolicy
+	typed               typedHandlers
+	metrics             Metrics
+
+	statsMu sync.Mutex
+	stats   StreamingStats
+
+	handlesMu                sync.RWMutex
+	handles                  map[*Subscription]struct{}
+	subscriptionEndedHandler func(params SubscriptionParams, event ErrorEvent)
+
+	writeMu   sync.Mutex
+	writeWait time.Duration
+
+	connectCtx       context.Context
+	dialer           *websocket.Dialer
+	readBufferSize   int
+	writeBufferSize  int
+	readLimit        int64
+	compression      bool
+	compressionSet   bool
+	compressionLevel int32
+	authHeader       string
+	authScheme       string
+
+	keepalivePeriod time.Duration
+	keepaliveFIGI   string
+	stallTimeout    time.Duration
+
+	done      chan struct{}
+	closeDone sync.Once
+	closeErr  error
+
+	connMu sync.RWMutex
+	swapMu sync.Mutex // serializes replacement of connection by reconnect and SetToken
+
+	state     int32
+	lastErrMu sync.Mutex
+	lastErr   error
+
+	events eventsChannels
+
+	requestIDPrefix string
+
+	waitersMu sync.Mutex
+	waiters   map[*eventWaiter]struct{}
+
+	onConnect    func()
+	onDisconnect func(err error)
+	onStale      func()
+	onReconnect  func(attempt int)
+
+	reconnectCfg    *ReconnectConfig
+	backoffAttempts int // used by read loop only
+	subsMu          sync.Mutex
+	subs            map[subscriptionKey]SubscriptionParams
 }
 
-func NewStreamingClient(logger Logger, token string) (*StreamingClient, error) {
-	return NewStreamingClientCustom(logger, token, StreamingApiURL)
+// StreamingOption configures streaming client.
+type StreamingOption func(*StreamingClient)
+
+// WithName sets name of streaming client. Name is prefixed to every log line of the client.
+func WithName(name string) StreamingOption {
+	return func(client *StreamingClient) {
+		client.name = name
+	}
 }
 
-func NewStreamingClientCustom(logger Logger, token, apiURL string) (*StreamingClient, error) {
-	return NewStreamingClientCustomPingPong(logger, token, apiURL, &PingPongConfig{false, DefaultPongWait, DefaultPingPeriod})
+// WithOnCallbackError sets policy of read loop for errors returned by callback.
+func WithOnCallbackError(policy CallbackErrorPolicy) StreamingOption {
+	return func(client *StreamingClient) {
+		client.callbackErrorPolicy = policy
+	}
 }
 
-func NewStreamingClientCustomPingPong(logger Logger, token, apiURL string, pingPongCfg *PingPongConfig) (*StreamingClient, error) {
+// WithDecoder sets decoder of messages, e.g. jsoniter or goccy/go-json.
+func WithDecoder(decoder Decoder) StreamingOption {
+	return func(client *StreamingClient) {
+		client.decoder = decoder
+	}
+}
+
+// WithAppKeepalive enables application level keepalive: instrument_info subscribe and unsubscribe
+// of figi are sent every period. It is fallback for proxies which drop websocket ping/pong frames.
+// Keepalive is skipped while figi is subscribed by user, and events of keepalive subscription aren't
+// passed to callback. Server can reply to every keepalive, so keep period large enough.
+func WithAppKeepalive(period time.Duration, figi string) StreamingOption {
+	return func(client *StreamingClient) {
+		client.keepalivePeriod = period
+		client.keepaliveFIGI = figi
+	}
+}
+
+// WithAuthHeader sets header name and scheme used to pass token on connect,
+// header value is "<scheme> <token>" or just token when scheme is empty.
+// By default "Authorization: Bearer <token>" is used.
+func WithAuthHeader(name, scheme string) StreamingOption {
+	return func(client *StreamingClient) {
+		client.authHeader = name
+		client.authScheme = scheme
+	}
+}
+
+// WithWriteWait sets deadline of each write to connection, DefaultWriteWait by default.
+// Write which exceeds it returns error and connection can't be used anymore.
+func WithWriteWait(wait time.Duration) StreamingOption {
+	return func(client *StreamingClient) {
+		client.writeWait = wait
+	}
+}
+
+// WithOnConnect sets hook called after every successful connect, including reconnects.
+// Client is already Connected when hook is called, so it can subscribe.
+func WithOnConnect(fn func()) StreamingOption {
+	return func(client *StreamingClient) {
+		client.onConnect = fn
+	}
+}
+
+// WithOnDisconnect sets hook called when read loop gets connection error, before reconnect.
+// It isn't called when connection is closed by Close.
+func WithOnDisconnect(fn func(err error)) StreamingOption {
+	return func(client *StreamingClient) {
+		client.onDisconnect = fn
+	}
+}
+
+// WithOnReconnect sets hook called after connection is re-established and subscriptions are replayed,
+// attempt is number of successful connect attempt. It is called after hook of WithOnConnect.
+func WithOnReconnect(fn func(attempt int)) StreamingOption {
+	return func(client *StreamingClient) {
+		client.onReconnect = fn
+	}
+}
+
+// WithDialer sets websocket dialer, e.g. with custom handshake timeout, proxy or TLS config.
+// By default dialer with proxy from environment and 5 seconds handshake timeout is used.
+func WithDialer(dialer *websocket.Dialer) StreamingOption {
+	return func(client *StreamingClient) {
+		client.dialer = dialer
+	}
+}
+
+// WithUnmarshaler sets unmarshal function of messages, e.g. jsoniter.Unmarshal, it is shortcut for
+// WithDecoder(DecoderFunc(unmarshal)). Message is unmarshaled twice: into small struct to peek event name
+// and then into typed event, the second one is skipped for events rejected by SetEventFilter.
+func WithUnmarshaler(unmarshal func(data []byte, v interface{}) error) StreamingOption {
+	return WithDecoder(DecoderFunc(unmarshal))
+}
+
+// WithReadBufferSize sets read buffer size of websocket dialer, it reduces syscalls for large messages.
+func WithReadBufferSize(size int) StreamingOption {
+	return func(client *StreamingClient) {
+		client.readBufferSize = size
+	}
+}
+
+// WithWriteBufferSize sets write buffer size of websocket dialer. Message larger than buffer is sent
+// in several frames. Writes are synchronous, batch subscribe methods write messages one by one under
+// single write lock, so there is no queue to size.
+func WithWriteBufferSize(size int) StreamingOption {
+	return func(client *StreamingClient) {
+		client.writeBufferSize = size
+	}
+}
+
+// WithReadLimit sets max size of received message, there is no limit by default. Larger message closes
+// connection with CloseMessageTooBig, read loop gets ErrConnectionClosed and reconnects when it is enabled.
+func WithReadLimit(limit int64) StreamingOption {
+	return func(client *StreamingClient) {
+		client.readLimit = limit
+	}
+}
+
+// WithCompression enables permessage-deflate compression when server supports it, it overrides
+// EnableCompression of dialer set by WithDialer. It reduces traffic of events at cost of CPU
+// for deflate and inflate, see BenchmarkCompression. Read limit applies to compressed size,
+// so decoded message can be larger than limit.
+func WithCompression(enabled bool) StreamingOption {
+	return func(client *StreamingClient) {
+		client.compression = enabled
+		client.compressionSet = true
+	}
+}
+
+// DefaultCompressionLevel is compression level of sent messages used by websocket by default, flate.BestSpeed.
+const DefaultCompressionLevel = flate.BestSpeed
+
+// WithCompressionLevel sets compression level of sent messages from flate.HuffmanOnly to flate.BestCompression,
+// DefaultCompressionLevel by default. It is used when compression is negotiated, see WithCompression.
+// Constructor returns ErrCompressionLevel for invalid level.
+func WithCompressionLevel(level int) StreamingOption {
+	return func(client *StreamingClient) {
+		client.compressionLevel = int32(level)
+	}
+}
+
+// SetCompressionLevel sets compression level of next messages for current and reconnected connections,
+// see WithCompressionLevel.
+func (c *StreamingClient) SetCompressionLevel(level int) error {
+	if !isCompressionLevel(level) {
+		return ErrCompressionLevel
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	atomic.StoreInt32(&c.compressionLevel, int32(level))
+
+	return c.getConn().SetCompressionLevel(level)
+}
+
+func isCompressionLevel(level int) bool {
+	return level >= flate.HuffmanOnly && level <= flate.BestCompression
+}
+
+func NewStreamingClient(logger Logger, token string, options ...StreamingOption) (*StreamingClient, error) {
+	return NewStreamingClientCustom(logger, token, StreamingApiURL, options...)
+}
+
+func NewStreamingClientCustom(logger Logger, token, apiURL string, options ...StreamingOption) (*StreamingClient, error) {
+	return NewStreamingClientCustomPingPong(logger, token, apiURL, &PingPongConfig{false, DefaultPongWait, DefaultPingPeriod}, options...)
+}
+
+// WithLogger sets logger of streaming client, logs are discarded by default, see NewStreamingClientWithOptions.
+func WithLogger(logger Logger) StreamingOption {
+	return func(client *StreamingClient) {
+		client.logger = logger
+	}
+}
+
+// WithStreamingURL sets streaming api url, StreamingApiURL by default.
+func WithStreamingURL(apiURL string) StreamingOption {
+	return func(client *StreamingClient) {
+		client.apiURL = apiURL
+	}
+}
+
+// WithContext sets ctx cancelling connection establishment of constructor, e.g. of NewStreamingClientWithOptions.
+// It overrides ctx of NewStreamingClientContext, reconnects aren't cancelled by it.
+func WithContext(ctx context.Context) StreamingOption {
+	return func(client *StreamingClient) {
+		client.connectCtx = ctx
+	}
+}
+
+// NewStreamingClientWithOptions returns new streaming client configured by options only,
+// e.g. WithLogger, WithStreamingURL, WithPingPong, WithDialer, WithReconnect and WithContext.
+func NewStreamingClientWithOptions(token string, options ...StreamingOption) (*StreamingClient, error) {
+	return newStreamingClient(
+		context.Background(),
+		discardLogger{},
+		token,
+		StreamingApiURL,
+		&PingPongConfig{false, DefaultPongWait, DefaultPingPeriod},
+		options...,
+	)
+}
+
+// NewStreamingClientContext returns new streaming client, ctx cancels connection establishment.
+func NewStreamingClientContext(ctx context.Context, logger Logger, token string, options ...StreamingOption) (*StreamingClient, error) {
+	return newStreamingClient(ctx, logger, token, StreamingApiURL, &PingPongConfig{false, DefaultPongWait, DefaultPingPeriod}, options...)
+}
+
+func NewStreamingClientCustomPingPong(
+	logger Logger,
+	token, apiURL string,
+	pingPongCfg *PingPongConfig,
+	options ...StreamingOption,
+) (*StreamingClient, error) {
+	return newStreamingClient(context.Background(), logger, token, apiURL, pingPongCfg, options...)
+}
+
+func newStreamingClient(
+	ctx context.Context,
+	logger Logger,
+	token, apiURL string,
+	pingPongCfg *PingPongConfig,
+	options ...StreamingOption,
+) (*StreamingClient, error) {
 	client := &StreamingClient{
 		logger: logger,
 		token:  token,
 		apiURL: apiURL,
 
 		pingPongCfg: pingPongCfg,
+		decoder:     jsonDecoder{},
+		metrics:     noopMetrics{},
+		handles:     make(map[*Subscription]struct{}),
+		subs:        make(map[subscriptionKey]SubscriptionParams),
+		waiters:     make(map[*eventWaiter]struct{}),
+		done:        make(chan struct{}),
+		events:      eventsChannels{bufferSize: DefaultEventsBufferSize},
+		authHeader:  "Authorization",
+		writeWait:   DefaultWriteWait,
+		readLimit:   DefaultReadLimit,
+
+		compressionLevel: DefaultCompressionLevel,
+
+		requestIDPrefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+		authScheme:      "Bearer",
 	}
 
-	conn, err := client.connect()
+	for i := range options {
+		options[i](client)
+	}
+	if err := client.pingPongCfg.validate(); err != nil {
+		return nil, err
+	}
+	if !isCompressionLevel(int(client.compressionLevel)) {
+		return nil, ErrCompressionLevel
+	}
+	if client.connectCtx != nil {
+		ctx = client.connectCtx
+	}
+
+	conn, err := client.connectInitial(ctx)
 	if err != nil {
 		return nil, err
 	}
-	client.conn = conn
+	client.setConn(conn)
+	client.setState(Connected)
+	if client.onConnect != nil {
+		client.onConnect()
+	}
+
+	if client.keepalivePeriod > 0 {
+		go client.runAppKeepalive()
+	}
+	if client.stallTimeout > 0 {
+		go client.runStallCheck()
+	}
 
 	return client, nil
 }
 
+// Close sends close frame to server and closes connection. Error of close frame sending
+// is ignored, so Close works for broken connection too. Close is safe to call from any
+// goroutine and more than once, next calls return result of the first one.
+// RunReadLoop returns nil after Close.
 func (c *StreamingClient) Close() error {
-	c.pingTicker.Stop()
+	c.closeDone.Do(func() {
+		close(c.done)
+		c.setState(Closed)
+		c.stopPing()
+
+		conn := c.getConn()
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		_ = c.writeControl(conn, websocket.CloseMessage, closeMsg, closeWait)
+
+		c.closeErr = conn.Close()
+	})
+
+	return c.closeErr
+}
+
+// CloseGraceful unsubscribes from all active subscriptions and closes client.
+// Unsubscribes are stopped when ctx is done or connection is broken, client is closed anyway.
+func (c *StreamingClient) CloseGraceful(ctx context.Context) error {
+	if c.State() == Connected {
+		if err := c.unsubscribeAll(ctx); err != nil {
+			c.logf(logWarn, "Can't unsubscribe on close: %v", err)
+		}
+	}
+
+	return c.Close()
+}
+
+// UnsubscribeAll unsubscribes from all active subscriptions, it stops on first failed unsubscribe.
+// It returns ErrReconnecting while subscriptions are replayed after reconnect.
+func (c *StreamingClient) UnsubscribeAll() error {
+	return c.unsubscribeAll(context.Background())
+}
+
+func (c *StreamingClient) unsubscribeAll(ctx context.Context) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.State() == Reconnecting {
+		return ErrReconnecting
+	}
 
-	return c.conn.Close()
+	for _, params := range c.activeSubscriptions() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msg, err := json.Marshal(params.request("unsubscribe"))
+		if err != nil {
+			return errors.Wrap(err, "can't marshal message")
+		}
+		if err := c.writeTextLockedDeadline(msg, c.writeDeadline(ctx)); err != nil {
+			return errors.Wrap(err, "can't unsubscribe from event")
+		}
+		c.untrack(params)
+	}
+
+	return nil
+}
+
+// writeDeadline returns deadline of write, it is deadline of ctx when it is earlier than write wait.
+func (c *StreamingClient) writeDeadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.writeWait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+
+	return deadline
+}
+
+// SetEventFilter sets filter by event name. Events rejected by filter aren't passed to callback
+// and registered handlers and are skipped before decoding of payload, so only the lightweight event
+// name peek is done for them. Error events and events needed by subscription handles and waiters,
+// e.g. of SubscribeCandleAck, are still decoded for them.
+// Without filter and RunReadLoop callback it is derived from handlers, see OnCandle.
+// Should be called before RunReadLoop.
+func (c *StreamingClient) SetEventFilter(filter func(name string) bool) {
+	c.eventFilter = filter
+}
+
+// SetRawMessageHandler sets handler called for every received frame before decoding,
+// including frames which can't be decoded. Should be called before RunReadLoop.
+func (c *StreamingClient) SetRawMessageHandler(handler func(messageType int, data []byte)) {
+	c.rawMessageHandler = handler
+}
+
+// SetUnknownEventHandler sets handler for events with unknown name and events which can't be decoded,
+// name is empty when it can't be decoded too. By default such events are logged.
+// Should be called before RunReadLoop.
+func (c *StreamingClient) SetUnknownEventHandler(handler func(name string, raw []byte)) {
+	c.unknownEventHandler = handler
 }
 
 func (c *StreamingClient) RunReadLoop(fn func(event interface{}) error) error {
+	return c.runReadLoop(context.Background(), withoutRaw(fn))
+}
+
+// RunReadLoopRaw runs read loop passing decoded event with received message to fn, e.g. to record feed.
+// Message slice isn't reused by next reads, so it can be kept.
+func (c *StreamingClient) RunReadLoopRaw(fn func(event interface{}, raw []byte) error) error {
+	return c.runReadLoop(context.Background(), fn)
+}
+
+func withoutRaw(fn func(event interface{}) error) func(event interface{}, raw []byte) error {
+	if fn == nil {
+		return nil
+	}
+
+	return func(event interface{}, _ []byte) error {
+		return fn(event)
+	}
+}
+
+// RunReadLoopContext runs read loop until ctx is done. When ctx is done client is closed
+// with close frame and ctx.Err() is returned.
+func (c *StreamingClient) RunReadLoopContext(ctx context.Context, fn func(event interface{}) error) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-stop:
+		}
+	}()
+
+	return c.runReadLoop(ctx, withoutRaw(fn))
+}
+
+// RunReadLoopWithContext runs read loop until ctx is done, it is the same as RunReadLoopContext.
+func (c *StreamingClient) RunReadLoopWithContext(ctx context.Context, fn func(event interface{}) error) error {
+	return c.RunReadLoopContext(ctx, fn)
+}
+
+func (c *StreamingClient) runReadLoop(ctx context.Context, fn func(event interface{}, raw []byte) error) error {
 	for {
-		_, msg, err := c.conn.ReadMessage()
+		conn := c.getConn()
+		messageType, msg, err := conn.ReadMessage()
 		if err != nil {
-			return errors.Wrap(err, "can't read message")
+			if conn != c.getConn() && !c.isClosed() {
+				// connection is replaced by SetToken
+				continue
+			}
+
+			err = classifyReadError(err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if c.isClosed() {
+				return nil
+			}
+
+			if errors.Is(err, ErrPongTimeout) {
+				c.metrics.PongTimeout()
+				if c.onStale != nil {
+					c.onStale()
+				}
+			}
+			c.setLastError(err)
+			if c.onDisconnect != nil {
+				c.onDisconnect(err)
+			}
+			if c.reconnectCfg == nil {
+				c.setState(Closed)
+				return errors.Wrap(err, "can't read message")
+			}
+
+			c.logf(logWarn, "Can't read message %v, reconnecting", err)
+			c.setState(Reconnecting)
+			c.metrics.Reconnect()
+			if reconnectErr := c.reconnect(); reconnectErr != nil {
+				if c.isClosed() {
+					return nil
+				}
+				c.setLastError(reconnectErr)
+				c.setState(Closed)
+				return errors.Wrapf(err, "can't read message, reconnect failed: %v", reconnectErr)
+			}
+			continue
+		}
+		atomic.StoreInt64(&c.lastMessageAt, time.Now().UnixNano())
+
+		if c.rawMessageHandler != nil {
+			c.rawMessageHandler(messageType, msg)
 		}
 
 		var event Event
-		if err := json.Unmarshal(msg, &event); err != nil {
-			c.logger.Printf("Can't unmarshal event %s", msg)
+		if err := c.decoder.Unmarshal(msg, &event); err != nil {
+			c.metrics.EventReceived("")
+			c.unknownEvent("", msg, "Can't unmarshal event %s")
+			continue
+		}
+		c.metrics.EventReceived(event.Name)
+
+		decode, deliver := c.shouldDecode(event.Name, fn != nil)
+		if !decode {
 			continue
 		}
 
+		var decoded StreamEvent
 		switch event.Name {
 		case "candle":
 			var event CandleEvent
-			if err := json.Unmarshal(msg, &event); err != nil {
-				c.logger.Printf("Can't unmarshal event candle %s", msg)
+			if err := c.decoder.Unmarshal(msg, &event); err != nil {
+				c.unknownEvent("candle", msg, "Can't unmarshal event candle %s")
 				continue
 			}
-			if err := fn(event); err != nil {
-				return err
-			}
+			decoded = event
 		case "orderbook":
 			var event OrderBookEvent
-			if err := json.Unmarshal(msg, &event); err != nil {
-				c.logger.Printf("Can't unmarshal event orderbook %s", msg)
+			if err := c.decoder.Unmarshal(msg, &event); err != nil {
+				c.unknownEvent("orderbook", msg, "Can't unmarshal event orderbook %s")
 				continue
 			}
-			if err := fn(event); err != nil {
-				return err
-			}
+			decoded = event
 		case "instrument_info":
 			var event InstrumentInfoEvent
-			if err := json.Unmarshal(msg, &event); err != nil {
-				c.logger.Printf("Can't unmarshal event instrument_info %s", msg)
+			if err := c.decoder.Unmarshal(msg, &event); err != nil {
+				c.unknownEvent("instrument_info", msg, "Can't unmarshal event instrument_info %s")
 				continue
 			}
-			if err := fn(event); err != nil {
-				return err
+			decoded = event
+		case "trading_status":
+			var event TradingStatusEvent
+			if err := c.decoder.Unmarshal(msg, &event); err != nil {
+				c.unknownEvent("trading_status", msg, "Can't unmarshal event trading_status %s")
+				continue
 			}
+			decoded = event
 		case "error":
 			var event ErrorEvent
-			if err := json.Unmarshal(msg, &event); err != nil {
-				c.logger.Printf("Can't unmarshal event error %s", msg)
+			if err := c.decoder.Unmarshal(msg, &event); err != nil {
+				c.unknownEvent("error", msg, "Can't unmarshal event error %s")
 				continue
 			}
-			if err := fn(event); err != nil {
-				return err
-			}
+			decoded = event
 		default:
-			c.logger.Printf("Get unknown event %s", msg)
+			c.unknownEvent(event.Name, msg, "Get unknown event %s")
+			continue
+		}
+		if c.isKeepaliveEvent(decoded) {
+			continue
+		}
+
+		c.dispatchWaiters(decoded)
+		c.dispatchSubscriptions(decoded)
+		if !deliver {
+			continue
+		}
+		if err := c.handleCallbackError(c.dispatchTyped(decoded)); err != nil {
+			return err
+		}
+
+		if fn == nil {
+			continue
+		}
+		if err := c.handleCallbackError(fn(decoded, msg)); err != nil {
+			return err
 		}
 	}
 }
 
+func (c *StreamingClient) unknownEvent(name string, msg []byte, format string) {
+	if name == "" || isKnownEventName(name) {
+		c.metrics.DecodeError(name)
+	}
+
+	if c.unknownEventHandler != nil {
+		c.unknownEventHandler(name, msg)
+		return
+	}
+
+	level := logWarn
+	if name != "" && !isKnownEventName(name) {
+		level = logDebug
+	}
+	c.logf(level, format+" (%d bytes)", msg, len(msg))
+}
+
+func (c *StreamingClient) handleCallbackError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch c.callbackErrorPolicy {
+	case CallbackErrorContinue:
+		return nil
+	case CallbackErrorContinueAndLog:
+		c.logf(logError, "Callback error %v", err)
+		return nil
+	default:
+		return err
+	}
+}
+
+// requestID returns id or generated unique id when id is empty.
+func (c *StreamingClient) requestID(id string) string {
+	if id != "" {
+		return id
+	}
+
+	return c.requestIDPrefix + "-" + strconv.FormatUint(atomic.AddUint64(&c.requestIDCounter, 1), 10)
+}
+
+type subscribeRequest struct {
+	Event     string         `json:"event"`
+	RequestID string         `json:"request_id"`
+	FIGI      string         `json:"figi"`
+	Interval  CandleInterval `json:"interval,omitempty"`
+	Depth     int            `json:"depth,omitempty"`
+}
+
+// SubscribeCandle rejects unsupported intervals with ErrInterval before sending.
+// Instrument info events don't contain intervals available for instrument,
+// so interval is checked against known CandleInterval constants only.
 func (c *StreamingClient) SubscribeCandle(figi string, interval CandleInterval, requestID string) error {
-	sub := `{ "event": "candle:subscribe", "request_id": "` + requestID + `", "figi": "` + figi + `", "interval": "` + string(interval) + `"}`
+	_, err := c.SubscribeCandleID(figi, interval, requestID)
 
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
-		return errors.Wrap(err, "can't subscribe to event")
+	return err
+}
+
+// SubscribeCandleID subscribes like SubscribeCandle and returns request id of subscription,
+// it is generated one for empty requestID or id of previous subscribe when subscription is already active.
+func (c *StreamingClient) SubscribeCandleID(figi string, interval CandleInterval, requestID string) (string, error) {
+	if !IsValidCandleInterval(interval) {
+		return "", ErrInterval
 	}
 
-	return nil
+	return c.subscribeTrackedID(SubscriptionParams{Kind: KindCandle, FIGI: figi, Interval: interval, RequestID: requestID})
 }
 
 func (c *StreamingClient) UnsubscribeCandle(figi string, interval CandleInterval, requestID string) error {
-	sub := `{ "event": "candle:unsubscribe", "request_id": "` + requestID + `", "figi": "` + figi + `", "interval": "` + string(interval) + `"}`
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
-		return errors.Wrap(err, "can't unsubscribe from event")
+	if !IsValidCandleInterval(interval) {
+		return ErrInterval
 	}
 
-	return nil
+	return c.unsubscribeTracked(SubscriptionParams{Kind: KindCandle, FIGI: figi, Interval: interval, RequestID: requestID})
 }
 
 func (c *StreamingClient) SubscribeOrderbook(figi string, depth int, requestID string) error {
-	if depth < 1 || depth > MaxOrderbookDepth {
-		return ErrDepth
-	}
+	_, err := c.SubscribeOrderbookID(figi, depth, requestID)
+
+	return err
+}
 
-	sub := `{ "event": "orderbook:subscribe", "request_id": "` + requestID + `", "figi": "` + figi + `", "depth": ` + strconv.Itoa(depth) + `}`
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
-		return errors.Wrap(err, "can't subscribe to event")
+// SubscribeOrderbookID subscribes like SubscribeOrderbook and returns request id of subscription, see SubscribeCandleID.
+func (c *StreamingClient) SubscribeOrderbookID(figi string, depth int, requestID string) (string, error) {
+	if depth < 1 || depth > MaxOrderbookDepth {
+		return "", ErrDepth
 	}
 
-	return nil
+	return c.subscribeTrackedID(SubscriptionParams{Kind: KindOrderBook, FIGI: figi, Depth: depth, RequestID: requestID})
 }
 
 func (c *StreamingClient) UnsubscribeOrderbook(figi string, depth int, requestID string) error {
@@ -161,28 +830,88 @@ func (c *StreamingClient) UnsubscribeOrderbook(figi string, depth int, requestID
 		return ErrDepth
 	}
 
-	sub := `{ "event": "orderbook:unsubscribe", "request_id": "` + requestID + `", "figi": "` + figi + `", "depth": ` + strconv.Itoa(depth) + `}`
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
-		return errors.Wrap(err, "can't unsubscribe from event")
-	}
-
-	return nil
+	return c.unsubscribeTracked(SubscriptionParams{Kind: KindOrderBook, FIGI: figi, Depth: depth, RequestID: requestID})
 }
 
 func (c *StreamingClient) SubscribeInstrumentInfo(figi, requestID string) error {
-	sub := `{"event": "instrument_info:subscribe", "request_id": "` + requestID + `", "figi": "` + figi + `"}`
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
-		return errors.Wrap(err, "can't subscribe to event")
-	}
+	_, err := c.SubscribeInstrumentInfoID(figi, requestID)
 
-	return nil
+	return err
+}
+
+// SubscribeInstrumentInfoID subscribes like SubscribeInstrumentInfo and returns request id of subscription,
+// see SubscribeCandleID.
+func (c *StreamingClient) SubscribeInstrumentInfoID(figi, requestID string) (string, error) {
+	return c.subscribeTrackedID(SubscriptionParams{Kind: KindInstrumentInfo, FIGI: figi, RequestID: requestID})
 }
 
 func (c *StreamingClient) UnsubscribeInstrumentInfo(figi, requestID string) error {
-	sub := `{"event": "instrument_info:unsubscribe", "request_id": "` + requestID + `", "figi": "` + figi + `"}`
-	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(sub)); err != nil {
+	return c.unsubscribeTracked(SubscriptionParams{Kind: KindInstrumentInfo, FIGI: figi, RequestID: requestID})
+}
+
+// subscribeTrackedID is no-op when subscription is already active.
+// Empty request id is replaced by generated one, see ActiveSubscriptions. It returns ErrReconnecting while subscriptions are replayed after reconnect.
+func (c *StreamingClient) subscribeTrackedID(params SubscriptionParams) (string, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.subscribeLocked(params)
+}
+
+// subscribeLocked checks and tracks subscription under writeMu, so concurrent subscribes
+// of the same params write single message. It returns request id of active subscription,
+// it is id of previous subscribe when subscription is already active.
+func (c *StreamingClient) subscribeLocked(params SubscriptionParams) (string, error) {
+	if params.FIGI == "" {
+		return "", ErrFIGI
+	}
+	if c.State() == Reconnecting {
+		return "", ErrReconnecting
+	}
+	if active, ok := c.tracked(params); ok {
+		return active.RequestID, nil
+	}
+
+	params.RequestID = c.requestID(params.RequestID)
+
+	msg, err := json.Marshal(params.request("subscribe"))
+	if err != nil {
+		return "", errors.Wrap(err, "can't marshal message")
+	}
+	if err := c.writeTextLocked(msg); err != nil {
+		return "", errors.Wrap(err, "can't subscribe to event")
+	}
+	c.track(params)
+
+	return params.RequestID, nil
+}
+
+func (c *StreamingClient) unsubscribeTracked(params SubscriptionParams) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.unsubscribeLocked(params)
+}
+
+// unsubscribeLocked should be called with writeMu held.
+func (c *StreamingClient) unsubscribeLocked(params SubscriptionParams) error {
+	if params.FIGI == "" {
+		return ErrFIGI
+	}
+	if c.State() == Reconnecting {
+		return ErrReconnecting
+	}
+
+	params.RequestID = c.requestID(params.RequestID)
+
+	msg, err := json.Marshal(params.request("unsubscribe"))
+	if err != nil {
+		return errors.Wrap(err, "can't marshal message")
+	}
+	if err := c.writeTextLocked(msg); err != nil {
 		return errors.Wrap(err, "can't unsubscribe from event")
 	}
+	c.untrack(params)
 
 	return nil
 }
@@ -190,33 +919,259 @@ func (c *StreamingClient) UnsubscribeInstrumentInfo(figi, requestID string) erro
 var ErrForbidden = errors.New("invalid token")
 var ErrUnauthorized = errors.New("token not provided")
 
-func (c *StreamingClient) connect() (*websocket.Conn, error) {
-	dialer := websocket.Dialer{
-		Proxy:            http.ProxyFromEnvironment,
-		HandshakeTimeout: 5 * time.Second,
+// ErrInterval returned when candle interval is not one of CandleInterval constants.
+var ErrInterval = errors.New("invalid candle interval")
+
+// ErrFIGI returned when figi of subscription is empty.
+var ErrFIGI = errors.New("empty figi")
+
+// ErrUnsupportedInterval is alias of ErrInterval.
+var ErrUnsupportedInterval = ErrInterval
+
+// ErrConnectionClosed returned when connection to server is closed.
+var ErrConnectionClosed = errors.New("connection closed")
+
+// ErrReconnecting returned by subscribe methods while client reconnects and replays active subscriptions.
+var ErrReconnecting = errors.New("reconnecting")
+
+// ErrPingPong returned when ping period of PingPongConfig isn't positive or isn't less than pong wait.
+var ErrPingPong = errors.New("invalid ping/pong config. Ping period should be positive and less than pong wait")
+
+// ErrCompressionLevel returned when compression level isn't from flate.HuffmanOnly to flate.BestCompression.
+var ErrCompressionLevel = errors.New("invalid compression level")
+
+// ErrPongTimeout returned when pong is not received in time and read deadline is exceeded.
+var ErrPongTimeout = errors.New("pong timeout")
+
+// classifiedError matches kind by errors.Is and keeps original error.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e classifiedError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e classifiedError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e classifiedError) Unwrap() error {
+	return e.err
+}
+
+func classifyReadError(err error) error {
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return classifiedError{kind: ErrPongTimeout, err: err}
+	}
+	if isConnectionClosed(err) || err == websocket.ErrReadLimit {
+		return classifiedError{kind: ErrConnectionClosed, err: err}
+	}
+
+	return err
+}
+
+func isConnectionClosed(err error) bool {
+	if err == websocket.ErrCloseSent {
+		return true
+	}
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return true
+	}
+	if isNetClosed(err) {
+		return true
+	}
+
+	// connection is closed by server
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// keepaliveRequestID is request id of keepalive subscription, see WithAppKeepalive.
+const keepaliveRequestID = "keepalive"
+
+func (c *StreamingClient) runAppKeepalive() {
+	ticker := time.NewTicker(c.keepalivePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.sendKeepalive(); err != nil {
+				c.logf(logWarn, "Can't send keepalive %v", err)
+			}
+		}
+	}
+}
+
+// sendKeepalive subscribes and unsubscribes at once, so keepalive doesn't leave server subscription.
+// It is skipped while subscriptions are replayed and when figi is subscribed by user.
+func (c *StreamingClient) sendKeepalive() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	keepalive := SubscriptionParams{Kind: KindInstrumentInfo, FIGI: c.keepaliveFIGI, RequestID: keepaliveRequestID}
+	if c.State() != Connected {
+		return nil
+	}
+	if _, ok := c.tracked(keepalive); ok {
+		return nil
+	}
+
+	for _, action := range []string{"subscribe", "unsubscribe"} {
+		msg, err := json.Marshal(keepalive.request(action))
+		if err != nil {
+			return errors.Wrap(err, "can't marshal message")
+		}
+		if err := c.writeTextLocked(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isKeepaliveEvent reports whether event is caused by keepalive subscription and isn't subscribed by user.
+func (c *StreamingClient) isKeepaliveEvent(event StreamEvent) bool {
+	if c.keepalivePeriod <= 0 {
+		return false
+	}
+
+	var figi string
+	switch e := event.(type) {
+	case InstrumentInfoEvent:
+		figi = e.Info.FIGI
+	case TradingStatusEvent:
+		figi = e.Status.FIGI
+	case ErrorEvent:
+		return e.Error.RequestID == keepaliveRequestID
+	default:
+		return false
+	}
+	if figi != c.keepaliveFIGI {
+		return false
+	}
+
+	_, tracked := c.tracked(SubscriptionParams{Kind: KindInstrumentInfo, FIGI: figi})
+
+	return !tracked
+}
+
+func (c *StreamingClient) writeControl(conn *websocket.Conn, messageType int, data []byte, wait time.Duration) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return conn.WriteControl(messageType, data, time.Now().Add(wait))
+}
+
+func (c *StreamingClient) writeJSON(v interface{}) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "can't marshal message")
+	}
+
+	return c.writeText(msg)
+}
+
+func (c *StreamingClient) writeText(msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.writeTextLocked(msg)
+}
+
+// writeTextLocked should be called with writeMu held.
+func (c *StreamingClient) writeTextLocked(msg []byte) error {
+	return c.writeTextLockedDeadline(msg, time.Now().Add(c.writeWait))
+}
+
+func (c *StreamingClient) writeTextLockedDeadline(msg []byte, deadline time.Time) error {
+	conn := c.getConn()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return errors.Wrap(err, "can't set write deadline")
+	}
+
+	err := conn.WriteMessage(websocket.TextMessage, msg)
+	if err != nil && isConnectionClosed(err) {
+		return classifiedError{kind: ErrConnectionClosed, err: err}
+	}
+
+	return err
+}
+
+func (c *StreamingClient) authHeaders() http.Header {
+	c.tokenMu.RLock()
+	value := c.token
+	c.tokenMu.RUnlock()
+
+	if c.authScheme != "" {
+		value = c.authScheme + " " + value
 	}
 
-	conn, resp, err := dialer.Dial(c.apiURL, http.Header{"Authorization": {"Bearer " + c.token}})
+	header := http.Header{}
+	header.Set(c.authHeader, value)
+
+	return header
+}
+
+func (c *StreamingClient) connect(ctx context.Context) (*websocket.Conn, error) {
+	dialer := c.dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 5 * time.Second,
+		}
+	}
+	if c.readBufferSize > 0 || c.writeBufferSize > 0 || c.compressionSet {
+		custom := *dialer
+		if c.readBufferSize > 0 {
+			custom.ReadBufferSize = c.readBufferSize
+		}
+		if c.writeBufferSize > 0 {
+			custom.WriteBufferSize = c.writeBufferSize
+		}
+		if c.compressionSet {
+			custom.EnableCompression = c.compression
+		}
+		dialer = &custom
+	}
+
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, c.apiURL, c.authHeaders())
 	if err != nil {
 		if resp != nil {
 			if resp.StatusCode == http.StatusForbidden {
+				c.observeConnect(start, ConnectForbidden)
 				return nil, ErrForbidden
 			}
 			if resp.StatusCode == http.StatusUnauthorized {
+				c.observeConnect(start, ConnectUnauthorized)
 				return nil, ErrUnauthorized
 			}
 
+			c.observeConnect(start, ConnectOther)
 			return nil, errors.Wrapf(err, "can't connect to %s %s", c.apiURL, resp.Status)
 		}
+		c.observeConnect(start, ConnectOther)
 		return nil, errors.Wrapf(err, "can't connect to %s", c.apiURL)
 	}
+	c.observeConnect(start, ConnectSuccess)
 	defer resp.Body.Close()
 
+	conn.SetReadLimit(c.readLimit)
+	if err := conn.SetCompressionLevel(int(atomic.LoadInt32(&c.compressionLevel))); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
 	if c.pingPongCfg.isEnabled {
 		conn.SetReadDeadline(time.Now().Add(c.pingPongCfg.pongWait))
 
 		conn.SetPingHandler(func(message string) error {
-			err := conn.WriteControl(websocket.PongMessage, []byte(message), time.Now().Add(time.Second))
+			err := c.writeControl(conn, websocket.PongMessage, []byte(message), time.Second)
 			if err == websocket.ErrCloseSent {
 				return nil
 			} else if e, ok := err.(net.Error); ok && e.Temporary() {
@@ -225,21 +1180,52 @@ func (c *StreamingClient) connect() (*websocket.Conn, error) {
 			return err
 		})
 
-		conn.SetPongHandler(func(string) error {
+		conn.SetPongHandler(func(message string) error {
 			conn.SetReadDeadline(time.Now().Add(c.pingPongCfg.pongWait))
+			if sentAt, err := strconv.ParseInt(message, 10, 64); err == nil {
+				atomic.StoreInt64(&c.lastRTT, time.Now().UnixNano()-sentAt)
+			}
 			return nil
 		})
 
+		c.pingMu.Lock()
+		if c.pingTicker != nil {
+			c.pingTicker.Stop()
+			close(c.pingStop)
+		}
 		c.pingTicker = time.NewTicker(c.pingPongCfg.pingPeriod)
+		c.pingStop = make(chan struct{})
+		go c.runPing(conn, c.pingTicker, c.pingStop)
+		c.pingMu.Unlock()
+	}
 
-		go func() {
-			<-c.pingTicker.C
+	return conn, nil
+}
 
-			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+func (c *StreamingClient) runPing(conn *websocket.Conn, ticker *time.Ticker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// pong echoes ping payload, so send time is used to measure round trip
+			sentAt := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+			if err := c.writeControl(conn, websocket.PingMessage, sentAt, c.writeWait); err != nil {
 				return
 			}
-		}()
+		}
 	}
+}
 
-	return conn, nil
+func (c *StreamingClient) stopPing() {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+
+	if c.pingTicker == nil {
+		return
+	}
+
+	c.pingTicker.Stop()
+	close(c.pingStop)
+	c.pingTicker = nil
 }
@@ -0,0 +1,688 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+const testTimeout = 2 * time.Second
+
+func newTestClient(t testing.TB, srv *sdktest.Server, options ...StreamingOption) *StreamingClient {
+	t.Helper()
+
+	client, err := NewStreamingClientCustom(discardLogger{}, "token", srv.URL(), options...)
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func runReadLoop(client *StreamingClient) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.RunReadLoop(nil)
+	}()
+
+	return errc
+}
+
+func nextRequest(t testing.TB, srv *sdktest.Server) sdktest.Request {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	req, err := srv.NextRequest(ctx)
+	if err != nil {
+		t.Fatalf("no request received: %v", err)
+	}
+
+	return req
+}
+
+func noRequest(t testing.TB, srv *sdktest.Server) {
+	t.Helper()
+
+	select {
+	case req := <-srv.Requests():
+		t.Fatalf("unexpected request %+v", req)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func waitErr(t testing.TB, errc <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatal("timeout")
+		return nil
+	}
+}
+
+func nextEvent(t testing.TB, events <-chan StreamEvent) StreamEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(testTimeout):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestAppKeepalive(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, WithAppKeepalive(10*time.Millisecond, "KEEP"))
+	events := make(chan interface{}, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event
+		return nil
+	})
+
+	for _, want := range []string{"instrument_info:subscribe", "instrument_info:unsubscribe"} {
+		if req := nextRequest(t, srv); req.Event != want || req.FIGI != "KEEP" || req.RequestID != keepaliveRequestID {
+			t.Fatalf("unexpected request %+v, want %s", req, want)
+		}
+	}
+
+	for _, event := range []map[string]interface{}{
+		{"event": "instrument_info", "payload": map[string]interface{}{"figi": "KEEP", "trade_status": "normal_trading"}},
+		{"event": "error", "payload": map[string]interface{}{"request_id": keepaliveRequestID, "error": "error"}},
+		{"event": "candle", "payload": map[string]interface{}{"figi": "FIGI", "interval": "1min"}},
+	} {
+		if err := srv.Send(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case event := <-events:
+		if _, ok := event.(CandleEvent); !ok {
+			t.Fatalf("keepalive event %+v is passed to callback", event)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("no event")
+	}
+
+	if err := client.SubscribeInstrumentInfo("KEEP", "user"); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	for {
+		req := nextRequest(t, srv)
+		if req.RequestID == "user" {
+			break
+		}
+	}
+	noRequest(t, srv)
+
+	if err := srv.Send(map[string]interface{}{
+		"event":   "instrument_info",
+		"payload": map[string]interface{}{"figi": "KEEP", "trade_status": "normal_trading"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events:
+		if _, ok := event.(InstrumentInfoEvent); !ok {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("event of user subscription isn't passed to callback")
+	}
+}
+
+func TestSubscribeToClosedConnection(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	srv.DropConnections()
+
+	// first writes can be buffered by system before server reset is received
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		err = client.SubscribeInstrumentInfo("FIGI"+strconv.Itoa(i), "")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("subscribe to connection closed by server: %v", err)
+	}
+
+	client = newTestClient(t, srv)
+	client.getConn().Close()
+	if err := client.SubscribeInstrumentInfo("FIGI", ""); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("subscribe to closed connection: %v", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	large := `{"event":"candle","payload":{"figi":"` + strings.Repeat("F", 1<<21) + `"}}`
+
+	client := newTestClient(t, srv)
+	events := make(chan interface{}, 1)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event
+		return nil
+	})
+	if err := srv.SendRaw([]byte(large)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-events:
+	case <-time.After(testTimeout):
+		t.Fatal("message is limited by default")
+	}
+	client.Close()
+	waitConnections(t, srv, 0)
+
+	client = newTestClient(t, srv, WithReadLimit(1024))
+	errc := runReadLoop(client)
+	if err := srv.SendRaw([]byte(large)); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitErr(t, errc); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("read of message over limit: %v", err)
+	}
+}
+
+func waitConnections(t testing.TB, srv *sdktest.Server, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+	for srv.Connections() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections, want %d", srv.Connections(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscribeReturnsRequestID(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+
+	id, err := client.SubscribeCandleID("FIGI", CandleInterval1Min, "")
+	if err != nil || id == "" {
+		t.Fatalf("subscribe: %q, %v", id, err)
+	}
+	if req := nextRequest(t, srv); req.RequestID != id {
+		t.Fatalf("request id %q, returned %q", req.RequestID, id)
+	}
+
+	again, err := client.SubscribeCandleID("FIGI", CandleInterval1Min, "")
+	if err != nil || again != id {
+		t.Fatalf("subscribe of active subscription: %q, %v, want %q", again, err, id)
+	}
+
+	other, err := client.SubscribeOrderbookID("FIGI", 5, "")
+	if err != nil || other == id {
+		t.Fatalf("subscribe: %q, %v", other, err)
+	}
+	if id, err := client.SubscribeInstrumentInfoID("FIGI", "info"); err != nil || id != "info" {
+		t.Fatalf("subscribe with request id: %q, %v", id, err)
+	}
+}
+
+func TestCloseGraceful(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	if err := client.SubscribeInstrumentInfos([]string{"A", "B"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+	nextRequest(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := client.CloseGraceful(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	for _, figi := range []string{"A", "B"} {
+		if req := nextRequest(t, srv); req.Event != "instrument_info:unsubscribe" || req.FIGI != figi {
+			t.Fatalf("unexpected request %+v", req)
+		}
+	}
+}
+
+func TestWriteDeadline(t *testing.T) {
+	client := &StreamingClient{writeWait: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); !client.writeDeadline(ctx).Equal(deadline) {
+		t.Fatal("deadline of ctx isn't used")
+	}
+
+	if deadline := client.writeDeadline(context.Background()); time.Until(deadline) < 59*time.Second {
+		t.Fatalf("deadline %s, want write wait", deadline)
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	for _, cfg := range []*PingPongConfig{
+		NewPingPongConfig(time.Second, 0),
+		NewPingPongConfig(time.Second, -time.Second),
+		NewPingPongConfig(time.Second, time.Second),
+		NewPingPongConfig(time.Second, 2*time.Second),
+	} {
+		if _, err := NewStreamingClientCustomPingPong(discardLogger{}, "token", srv.URL(), cfg); err != ErrPingPong {
+			t.Fatalf("config %+v: %v", *cfg, err)
+		}
+	}
+	if n := len(srv.Headers()); n != 0 {
+		t.Fatalf("%d connections with invalid config", n)
+	}
+
+	client := newTestClient(t, srv, WithPingPong(time.Second, 10*time.Millisecond))
+	runReadLoop(client)
+
+	deadline := time.Now().Add(testTimeout)
+	for client.LastRTT() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("pong isn't received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewStreamingClientWithOptions("token", WithStreamingURL(srv.URL()), WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("connect with canceled ctx: %v", err)
+	}
+
+	client, err := NewStreamingClientWithOptions("token", WithStreamingURL(srv.URL()), WithContext(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+}
+
+func TestCloseConcurrently(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, WithPingPong(time.Second, 10*time.Millisecond))
+	errc := runReadLoop(client)
+
+	results := make(chan error, 10)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			results <- client.Close()
+		}()
+	}
+	first := waitErr(t, results)
+	for i := 1; i < cap(results); i++ {
+		if err := waitErr(t, results); err != first {
+			t.Fatalf("close result %v, first %v", err, first)
+		}
+	}
+	if err := waitErr(t, errc); err != nil {
+		t.Fatalf("read loop after close: %v", err)
+	}
+	if state := client.State(); state != Closed {
+		t.Fatalf("state %s", state)
+	}
+}
+
+const benchOrderBook = `{"event":"orderbook","time":"2019-08-07T15:35:00.029721253Z","payload":{"figi":"BBG0013HGFT4","depth":10,` +
+	`"bids":[[64.8,10],[64.79,20],[64.78,5],[64.77,50],[64.76,1],[64.75,11],[64.74,3],[64.73,8],[64.72,40],[64.71,2]],` +
+	`"asks":[[64.81,12],[64.82,7],[64.83,9],[64.84,30],[64.85,1],[64.86,6],[64.87,15],[64.88,4],[64.89,22],[64.9,3]]}}`
+
+// peekDecoder reads event name without full unmarshal, other values are unmarshaled by encoding/json.
+type peekDecoder struct{}
+
+func (peekDecoder) Unmarshal(data []byte, v interface{}) error {
+	event, ok := v.(*Event)
+	if !ok {
+		return json.Unmarshal(data, v)
+	}
+
+	const prefix = `{"event":"`
+	if !bytes.HasPrefix(data, []byte(prefix)) {
+		return json.Unmarshal(data, v)
+	}
+	end := bytes.IndexByte(data[len(prefix):], '"')
+	if end < 0 {
+		return json.Unmarshal(data, v)
+	}
+	event.Name = string(data[len(prefix) : len(prefix)+end])
+
+	return nil
+}
+
+// BenchmarkDecode decodes orderbook frame like read loop: event name first and then full event.
+func BenchmarkDecode(b *testing.B) {
+	msg := []byte(benchOrderBook)
+
+	for _, bench := range []struct {
+		name    string
+		decoder Decoder
+	}{
+		{name: "default", decoder: jsonDecoder{}},
+		{name: "custom", decoder: peekDecoder{}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(msg)))
+
+			for i := 0; i < b.N; i++ {
+				var event Event
+				if err := bench.decoder.Unmarshal(msg, &event); err != nil || event.Name != "orderbook" {
+					b.Fatalf("event %+v: %v", event, err)
+				}
+				var orderBook OrderBookEvent
+				if err := bench.decoder.Unmarshal(msg, &orderBook); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCloseWithoutPingTicker(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	// ping/pong is disabled, so ticker isn't created
+	client := newTestClient(t, srv)
+	errc := runReadLoop(client)
+	if err := client.Close(); err != nil {
+		t.Fatalf("close without ping: %v", err)
+	}
+	if err := waitErr(t, errc); err != nil {
+		t.Fatalf("read loop: %v", err)
+	}
+
+	// ticker is stopped before close, e.g. by failed reconnect
+	client = newTestClient(t, srv, WithPingPong(time.Second, 10*time.Millisecond))
+	client.stopPing()
+	if err := client.Close(); err != nil {
+		t.Fatalf("close with stopped ping: %v", err)
+	}
+	waitConnections(t, srv, 0)
+}
+
+func TestEventFilter(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	var orderBooksDecoded int32
+	client := newTestClient(t, srv, WithDecoder(DecoderFunc(func(data []byte, v interface{}) error {
+		if _, ok := v.(*OrderBookEvent); ok {
+			atomic.AddInt32(&orderBooksDecoded, 1)
+		}
+		return json.Unmarshal(data, v)
+	})))
+	client.SetEventFilter(func(name string) bool { return name != "orderbook" })
+
+	events := make(chan StreamEvent, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event.(StreamEvent)
+		return nil
+	})
+
+	if err := srv.SendRaw([]byte(benchOrderBook)); err != nil {
+		t.Fatal(err)
+	}
+	sendCandles(t, srv, 1)
+	if event := nextEvent(t, events); event.Kind() != KindCandle {
+		t.Fatalf("filtered event %+v reached callback", event)
+	}
+	if n := atomic.LoadInt32(&orderBooksDecoded); n != 0 {
+		t.Fatalf("filtered orderbook is decoded %d times", n)
+	}
+}
+
+// recordLogger keeps logged messages.
+type recordLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, msg := range l.messages {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCallbackErrorPolicy(t *testing.T) {
+	errSecond := errors.New("second event")
+
+	tests := []struct {
+		policy  CallbackErrorPolicy
+		stopped bool
+		logged  bool
+	}{
+		{policy: CallbackErrorStop, stopped: true},
+		{policy: CallbackErrorContinue},
+		{policy: CallbackErrorContinueAndLog, logged: true},
+	}
+	for _, tt := range tests {
+		srv := sdktest.NewServer()
+		logger := &recordLogger{}
+		client := newTestClient(t, srv, WithOnCallbackError(tt.policy), WithLogger(logger))
+
+		var received int32
+		errc := make(chan error, 1)
+		go func() {
+			errc <- client.RunReadLoop(func(interface{}) error {
+				if atomic.AddInt32(&received, 1) == 2 {
+					return errSecond
+				}
+				return nil
+			})
+		}()
+		sendCandles(t, srv, 1, 2, 3)
+
+		if tt.stopped {
+			if err := waitErr(t, errc); err != errSecond {
+				t.Fatalf("policy %d: read loop error %v", tt.policy, err)
+			}
+			if n := atomic.LoadInt32(&received); n != 2 {
+				t.Fatalf("policy %d: %d events received after stop", tt.policy, n)
+			}
+		} else {
+			deadline := time.Now().Add(testTimeout)
+			for atomic.LoadInt32(&received) != 3 {
+				if time.Now().After(deadline) {
+					t.Fatalf("policy %d: %d events received", tt.policy, atomic.LoadInt32(&received))
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		if got := logger.contains(errSecond.Error()); got != tt.logged {
+			t.Fatalf("policy %d: error is logged %t", tt.policy, got)
+		}
+
+		srv.Close()
+	}
+}
+
+func TestSubscribeCandleUnsupportedInterval(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	for _, interval := range []CandleInterval{"", "7min", "1year"} {
+		if err := client.SubscribeCandle("FIGI", interval, ""); !errors.Is(err, ErrUnsupportedInterval) {
+			t.Fatalf("subscribe with interval %q: %v", interval, err)
+		}
+		if err := client.UnsubscribeCandle("FIGI", interval, ""); !errors.Is(err, ErrUnsupportedInterval) {
+			t.Fatalf("unsubscribe with interval %q: %v", interval, err)
+		}
+	}
+	noRequest(t, srv)
+	if subs := client.ActiveSubscriptions(); len(subs) != 0 {
+		t.Fatalf("rejected subscriptions are tracked: %d", len(subs))
+	}
+
+	if err := client.SubscribeCandle("FIGI", CandleInterval1Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	if req := nextRequest(t, srv); req.Event != "candle:subscribe" || req.Interval != "hour" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+}
+
+func TestConcurrentSubscribe(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, WithPingPong(time.Second, time.Millisecond))
+	runReadLoop(client)
+
+	const n = 50
+	var wg sync.WaitGroup
+	errc := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(figi string) {
+			defer wg.Done()
+			errc <- client.SubscribeCandle(figi, CandleInterval1Min, "")
+			errc <- client.SubscribeOrderbook(figi, 10, "")
+		}("FIGI" + strconv.Itoa(i))
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+	}
+
+	for i := 0; i < 2*n; i++ {
+		nextRequest(t, srv)
+	}
+	if subs := client.activeSubscriptions(); len(subs) != 2*n {
+		t.Fatalf("%d active subscriptions", len(subs))
+	}
+}
+
+func TestRunReadLoopWithContext(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.RunReadLoopWithContext(ctx, func(interface{}) error { return nil })
+	}()
+
+	cancel()
+	if err := waitErr(t, errc); err != context.Canceled {
+		t.Fatalf("read loop error %v, want %v", err, context.Canceled)
+	}
+	if state := client.State(); state != Closed {
+		t.Fatalf("state %s", state)
+	}
+	waitConnections(t, srv, 0)
+}
+
+func TestEventFilterKeepsInternalEvents(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	client.SetEventFilter(func(name string) bool { return name == "candle" })
+	events := make(chan StreamEvent, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event.(StreamEvent)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.SubscribeCandleAck(ctx, "FIGI", CandleInterval1Min, "ack")
+	}()
+	nextRequest(t, srv)
+	if err := srv.Send(map[string]interface{}{
+		"event":   "error",
+		"payload": map[string]interface{}{"request_id": "ack", "error": "unknown figi"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var subErr *SubscriptionError
+	if err := waitErr(t, errc); !errors.As(err, &subErr) || subErr.RequestID != "ack" {
+		t.Fatalf("ack of rejected subscription: %v", err)
+	}
+	sendCandles(t, srv, 1)
+	if event := nextEvent(t, events); event.Kind() != KindCandle {
+		t.Fatalf("filtered event %+v reached callback", event)
+	}
+}
+
+func TestAuthHeader(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name, scheme string
+		want         string
+	}{
+		{name: "X-Api-Key", want: "token"},
+		{name: "X-Auth", scheme: "Token", want: "Token token"},
+	}
+	for _, tt := range tests {
+		newTestClient(t, srv, WithAuthHeader(tt.name, tt.scheme))
+
+		headers := srv.Headers()
+		header := headers[len(headers)-1]
+		if got := header.Get(tt.name); got != tt.want {
+			t.Fatalf("header %s is %q, want %q", tt.name, got, tt.want)
+		}
+		if got := header.Get("Authorization"); got != "" {
+			t.Fatalf("authorization %q is sent with custom header", got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+	"github.com/gorilla/websocket"
+)
+
+func TestCompression(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		options []StreamingOption
+		want    bool
+	}{
+		{name: "default"},
+		{name: "enabled", options: []StreamingOption{WithCompression(true)}, want: true},
+		{name: "enabled by dialer", options: []StreamingOption{WithDialer(&websocket.Dialer{EnableCompression: true})}, want: true},
+		{
+			name:    "disabled over dialer",
+			options: []StreamingOption{WithDialer(&websocket.Dialer{EnableCompression: true}), WithCompression(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestClient(t, srv, tt.options...)
+
+			headers := srv.Headers()
+			extensions := headers[len(headers)-1].Get("Sec-Websocket-Extensions")
+			if got := strings.Contains(extensions, "permessage-deflate"); got != tt.want {
+				t.Fatalf("compression %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewStreamingClientCustom(discardLogger{}, "token", srv.URL(), WithCompressionLevel(10)); err != ErrCompressionLevel {
+		t.Fatalf("invalid level: %v", err)
+	}
+	client := newTestClient(t, srv, WithCompression(true), WithCompressionLevel(9))
+	if err := client.SetCompressionLevel(-3); err != ErrCompressionLevel {
+		t.Fatalf("set invalid level: %v", err)
+	}
+	if err := client.SetCompressionLevel(DefaultCompressionLevel); err != nil {
+		t.Fatalf("set level: %v", err)
+	}
+	if err := client.SubscribeInstrumentInfo("FIGI", ""); err != nil {
+		t.Fatal(err)
+	}
+	if req := nextRequest(t, srv); req.FIGI != "FIGI" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+}
+
+// countingConn counts bytes read from network.
+type countingConn struct {
+	net.Conn
+	read *int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(c.read, int64(n))
+
+	return n, err
+}
+
+// BenchmarkCompression measures read loop of orderbook events with depth 20, wire-B/op is received traffic.
+func BenchmarkCompression(b *testing.B) {
+	var levels []string
+	for i := 0; i < 20; i++ {
+		levels = append(levels, fmt.Sprintf("[%.2f,%d]", 270.05+float64(i)*0.01, 10+i*7))
+	}
+	event := []byte(`{"event":"orderbook","time":"2019-08-07T15:35:00.029721253Z","payload":{"figi":"BBG0013HGFT4","depth":20,` +
+		`"bids":[` + strings.Join(levels, ",") + `],"asks":[` + strings.Join(levels, ",") + `]}}`)
+
+	for _, compression := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compression=%t", compression), func(b *testing.B) {
+			srv := sdktest.NewServer()
+			defer srv.Close()
+
+			var read int64
+			dialer := &websocket.Dialer{
+				NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					return countingConn{Conn: conn, read: &read}, nil
+				},
+			}
+			client := newTestClient(b, srv, WithDialer(dialer), WithCompression(compression))
+			received := make(chan struct{})
+			go client.RunReadLoop(func(interface{}) error {
+				received <- struct{}{}
+				return nil
+			})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := atomic.LoadInt64(&read)
+			for i := 0; i < b.N; i++ {
+				if err := srv.SendRaw(event); err != nil {
+					b.Fatal(err)
+				}
+				<-received
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&read)-start)/float64(b.N), "wire-B/op")
+		})
+	}
+}
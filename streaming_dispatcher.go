@@ -0,0 +1,69 @@
+package sdk
+
+import "sync"
+
+// Dispatcher routes streaming events to handlers by figi, use Dispatch as RunReadLoop callback.
+// Handlers can be registered and unregistered while read loop is running.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string]func(event interface{}) error
+	fallback func(event interface{}) error
+}
+
+// NewDispatcher returns new Dispatcher without handlers.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string]func(event interface{}) error)}
+}
+
+// Register sets handler of events with figi, previous handler of figi is replaced.
+func (d *Dispatcher) Register(figi string, handler func(event interface{}) error) {
+	d.mu.Lock()
+	d.handlers[figi] = handler
+	d.mu.Unlock()
+}
+
+// Unregister removes handler of figi.
+func (d *Dispatcher) Unregister(figi string) {
+	d.mu.Lock()
+	delete(d.handlers, figi)
+	d.mu.Unlock()
+}
+
+// SetFallback sets handler of events without registered handler, including error events.
+func (d *Dispatcher) SetFallback(handler func(event interface{}) error) {
+	d.mu.Lock()
+	d.fallback = handler
+	d.mu.Unlock()
+}
+
+// Dispatch calls handler of event figi or fallback handler. Events without handler are skipped.
+func (d *Dispatcher) Dispatch(event interface{}) error {
+	d.mu.RLock()
+	handler, ok := d.handlers[eventFIGI(event)]
+	if !ok {
+		handler = d.fallback
+	}
+	d.mu.RUnlock()
+
+	if handler == nil {
+		return nil
+	}
+
+	return handler(event)
+}
+
+// eventFIGI returns figi of event or empty string for events without figi.
+func eventFIGI(event interface{}) string {
+	switch e := event.(type) {
+	case CandleEvent:
+		return e.Candle.FIGI
+	case OrderBookEvent:
+		return e.OrderBook.FIGI
+	case InstrumentInfoEvent:
+		return e.Info.FIGI
+	case TradingStatusEvent:
+		return e.Status.FIGI
+	default:
+		return ""
+	}
+}
@@ -22,6 +22,44 @@ const (
 	CandleInterval1Month CandleInterval = "month"
 )
 
+// IsValidCandleInterval reports whether interval is one of CandleInterval constants.
+func IsValidCandleInterval(interval CandleInterval) bool {
+	return interval == CandleInterval1Month || interval.Duration() != 0
+}
+
+// Duration returns length of candle period. It returns 0 for intervals
+// without fixed duration (month) and for unknown intervals.
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case CandleInterval1Min:
+		return time.Minute
+	case CandleInterval2Min:
+		return 2 * time.Minute
+	case CandleInterval3Min:
+		return 3 * time.Minute
+	case CandleInterval5Min:
+		return 5 * time.Minute
+	case CandleInterval10Min:
+		return 10 * time.Minute
+	case CandleInterval15Min:
+		return 15 * time.Minute
+	case CandleInterval30Min:
+		return 30 * time.Minute
+	case CandleInterval1Hour:
+		return time.Hour
+	case CandleInterval2Hour:
+		return 2 * time.Hour
+	case CandleInterval4Hour:
+		return 4 * time.Hour
+	case CandleInterval1Day:
+		return 24 * time.Hour
+	case CandleInterval1Week:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
 type TradingStatus string
 
 const (
@@ -37,6 +75,49 @@ const (
 	TradingAtClosingAuctionPrice TradingStatus = "TradingAtClosingAuctionPrice"
 )
 
+type EventKind int
+
+const (
+	KindUnknown EventKind = iota
+	KindCandle
+	KindOrderBook
+	KindInstrumentInfo
+	KindError
+	KindTradingStatus
+)
+
+// String returns event name used by streaming api.
+func (k EventKind) String() string {
+	switch k {
+	case KindCandle:
+		return "candle"
+	case KindOrderBook:
+		return "orderbook"
+	case KindInstrumentInfo:
+		return "instrument_info"
+	case KindError:
+		return "error"
+	case KindTradingStatus:
+		return "trading_status"
+	default:
+		return "unknown"
+	}
+}
+
+func isKnownEventName(name string) bool {
+	switch name {
+	case "candle", "orderbook", "instrument_info", "trading_status", "error":
+		return true
+	default:
+		return false
+	}
+}
+
+// StreamEvent is implemented by all decoded streaming events.
+type StreamEvent interface {
+	Kind() EventKind
+}
+
 type Event struct {
 	Name string `json:"event"`
 }
@@ -51,6 +132,8 @@ type CandleEvent struct {
 	Candle Candle `json:"payload"`
 }
 
+func (CandleEvent) Kind() EventKind { return KindCandle }
+
 type Candle struct {
 	FIGI       string         `json:"figi"`
 	Interval   CandleInterval `json:"interval"`
@@ -62,11 +145,24 @@ type Candle struct {
 	TS         time.Time      `json:"time"`
 }
 
+// IsClosed reports whether candle period has fully elapsed relative to now.
+// It returns false for intervals without fixed duration.
+func (c Candle) IsClosed(interval CandleInterval, now time.Time) bool {
+	d := interval.Duration()
+	if d == 0 {
+		return false
+	}
+
+	return !now.Before(c.TS.Add(d))
+}
+
 type OrderBookEvent struct {
 	FullEvent
 	OrderBook OrderBook `json:"payload"`
 }
 
+func (OrderBookEvent) Kind() EventKind { return KindOrderBook }
+
 type OrderBook struct {
 	FIGI  string          `json:"figi"`
 	Depth int             `json:"depth"`
@@ -74,6 +170,46 @@ type OrderBook struct {
 	Asks  []PriceQuantity `json:"asks"`
 }
 
+// BestBid returns first bid, ok is false when there are no bids.
+func (o OrderBook) BestBid() (PriceQuantity, bool) {
+	if len(o.Bids) == 0 {
+		return PriceQuantity{}, false
+	}
+
+	return o.Bids[0], true
+}
+
+// BestAsk returns first ask, ok is false when there are no asks.
+func (o OrderBook) BestAsk() (PriceQuantity, bool) {
+	if len(o.Asks) == 0 {
+		return PriceQuantity{}, false
+	}
+
+	return o.Asks[0], true
+}
+
+// Spread returns difference of best ask and best bid prices, ok is false when any side is empty.
+func (o OrderBook) Spread() (float64, bool) {
+	bid, okBid := o.BestBid()
+	ask, okAsk := o.BestAsk()
+	if !okBid || !okAsk {
+		return 0, false
+	}
+
+	return ask[0] - bid[0], true
+}
+
+// MidPrice returns average of best ask and best bid prices, ok is false when any side is empty.
+func (o OrderBook) MidPrice() (float64, bool) {
+	bid, okBid := o.BestBid()
+	ask, okAsk := o.BestAsk()
+	if !okBid || !okAsk {
+		return 0, false
+	}
+
+	return (ask[0] + bid[0]) / 2, true
+}
+
 type PriceQuantity [2]float64 // 0 - price, 1 - quantity
 
 type InstrumentInfoEvent struct {
@@ -81,6 +217,8 @@ type InstrumentInfoEvent struct {
 	Info InstrumentInfo `json:"payload"`
 }
 
+func (InstrumentInfoEvent) Kind() EventKind { return KindInstrumentInfo }
+
 type InstrumentInfo struct {
 	FIGI              string        `json:"figi"`
 	TradeStatus       TradingStatus `json:"trade_status"`
@@ -91,11 +229,27 @@ type InstrumentInfo struct {
 	LimitDown         float64       `json:"limit_down,omitempty"`
 }
 
+// TradingStatusEvent is push with trading status of instrument. There is no subscription
+// for it, status is sent to clients subscribed to instrument.
+type TradingStatusEvent struct {
+	FullEvent
+	Status TradingStatusInfo `json:"payload"`
+}
+
+func (TradingStatusEvent) Kind() EventKind { return KindTradingStatus }
+
+type TradingStatusInfo struct {
+	FIGI        string        `json:"figi"`
+	TradeStatus TradingStatus `json:"trade_status"`
+}
+
 type ErrorEvent struct {
 	FullEvent
 	Error Error `json:"payload"`
 }
 
+func (ErrorEvent) Kind() EventKind { return KindError }
+
 type Error struct {
 	RequestID string `json:"request_id,omitempty"`
 	Error     string `json:"error"`
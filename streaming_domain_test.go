@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func TestEventTime(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	events := make(chan StreamEvent, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event.(StreamEvent)
+		return nil
+	})
+
+	for _, payload := range []string{
+		`{"event":"candle","time":"2019-08-07T18:35:00.029721253+03:00","payload":{"o":64.0575,"c":64.0575,"h":64.0575,"l":64.0575,"v":156,"time":"2019-08-07T18:35:00.5+03:00","interval":"5min","figi":"BBG0013HGFT4"}}`,
+		`{"event":"orderbook","time":"2019-08-07T18:35:00.029721253+03:00","payload":{"figi":"BBG0013HGFT4","depth":1,"bids":[[64.8, 10]],"asks":[[64.9, 20]]}}`,
+		`{"event":"instrument_info","time":"2019-08-07T18:35:00.029721253+03:00","payload":{"figi":"BBG0013HGFT4","trade_status":"normal_trading","min_price_increment":0.0025,"lot":1000}}`,
+	} {
+		if err := srv.SendRaw([]byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moscow := time.FixedZone("", 3*60*60)
+	eventTime := time.Date(2019, 8, 7, 18, 35, 0, 29721253, moscow)
+	for i := 0; i < 3; i++ {
+		var full FullEvent
+		switch event := nextEvent(t, events).(type) {
+		case CandleEvent:
+			full = event.FullEvent
+			ts := event.Candle.TS
+			if !ts.Equal(time.Date(2019, 8, 7, 15, 35, 0, 5e8, time.UTC)) {
+				t.Fatalf("candle time %s", ts)
+			}
+			if _, offset := ts.Zone(); offset != 3*60*60 {
+				t.Fatalf("candle time offset %d", offset)
+			}
+		case OrderBookEvent:
+			full = event.FullEvent
+		case InstrumentInfoEvent:
+			full = event.FullEvent
+		default:
+			t.Fatalf("unexpected event %+v", event)
+		}
+
+		if !full.Time.Equal(eventTime) {
+			t.Fatalf("%s event time %s, want %s", full.Name, full.Time, eventTime)
+		}
+		if _, offset := full.Time.Zone(); offset != 3*60*60 {
+			t.Fatalf("%s event time offset %d", full.Name, offset)
+		}
+	}
+}
+
+func TestCandleIsClosed(t *testing.T) {
+	ts := time.Date(2020, 1, 10, 10, 0, 0, 0, time.UTC)
+	candle := Candle{TS: ts}
+
+	tests := []struct {
+		name     string
+		interval CandleInterval
+		now      time.Time
+		want     bool
+	}{
+		{name: "start of period", interval: CandleInterval1Min, now: ts},
+		{name: "before end", interval: CandleInterval1Min, now: ts.Add(time.Minute - time.Nanosecond)},
+		{name: "end of period", interval: CandleInterval1Min, now: ts.Add(time.Minute), want: true},
+		{name: "after end", interval: CandleInterval5Min, now: ts.Add(6 * time.Minute), want: true},
+		{name: "before hour end", interval: CandleInterval1Hour, now: ts.Add(59 * time.Minute)},
+		{name: "end of day", interval: CandleInterval1Day, now: ts.Add(24 * time.Hour), want: true},
+		{name: "end of week", interval: CandleInterval1Week, now: ts.Add(7 * 24 * time.Hour), want: true},
+		{name: "month without fixed duration", interval: CandleInterval1Month, now: ts.AddDate(1, 0, 0)},
+		{name: "unknown interval", interval: "unknown", now: ts.AddDate(1, 0, 0)},
+		{name: "other time zone", interval: CandleInterval1Min, now: ts.Add(time.Minute).In(time.FixedZone("", 3*60*60)), want: true},
+	}
+	for _, tt := range tests {
+		if got := candle.IsClosed(tt.interval, tt.now); got != tt.want {
+			t.Errorf("%s: closed %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Errors of streaming api error events, see ErrorEvent.Err.
+var (
+	ErrInstrumentNotFound = errors.New("instrument not found")
+	ErrSubscriptionLimit  = errors.New("subscription limit exceeded")
+	ErrServer             = errors.New("server error")
+)
+
+// StreamError is error of error event. It matches sentinel by errors.Is and exposes
+// raw request id and message of event.
+type StreamError struct {
+	RequestID string
+	Message   string
+
+	kind error
+}
+
+// Error for implements error.
+func (e *StreamError) Error() string {
+	return e.kind.Error() + ": " + e.Message
+}
+
+// Unwrap returns sentinel error of event.
+func (e *StreamError) Unwrap() error {
+	return e.kind
+}
+
+// Err maps error event to *StreamError matching sentinel error by message of event:
+//   - message containing "not found" matches ErrInstrumentNotFound;
+//   - message containing "limit" matches ErrSubscriptionLimit;
+//   - any other message matches ErrServer.
+//
+// Server doesn't send error codes, so messages are matched case-insensitively.
+func (e ErrorEvent) Err() error {
+	msg := strings.ToLower(e.Error.Error)
+
+	kind := ErrServer
+	switch {
+	case strings.Contains(msg, "not found"):
+		kind = ErrInstrumentNotFound
+	case strings.Contains(msg, "limit"):
+		kind = ErrSubscriptionLimit
+	}
+
+	return &StreamError{RequestID: e.Error.RequestID, Message: e.Error.Error, kind: kind}
+}
@@ -0,0 +1,223 @@
+package sdk
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEventsBufferSize is default capacity of channel returned by Events.
+const DefaultEventsBufferSize = 100
+
+// OverflowPolicy defines what Events does when channel buffer is full.
+type OverflowPolicy int
+
+const (
+	// BlockOnFull blocks read loop until consumer reads event, events are not lost. It is default policy.
+	BlockOnFull OverflowPolicy = iota
+	// DropNewest drops event which doesn't fit into buffer.
+	DropNewest
+	// DropOldest drops oldest buffered event to put new one, consumer gets fresh events.
+	DropOldest
+)
+
+// eventsChannels are channels of Events, Candles and others. They are created on first call and
+// filled by single read loop, which is started by first of them and closes all channels on stop.
+type eventsChannels struct {
+	once        sync.Once
+	bufferSize  int
+	bufferSizes map[EventKind]int
+	overflow    OverflowPolicy
+
+	mu      sync.Mutex
+	stopped bool
+	err     error
+	all     chan interface{}
+	typed   map[EventKind]reflect.Value
+}
+
+// WithEventsBufferSize sets capacity of channel returned by Events and default capacity of
+// channels returned by Candles and others.
+func WithEventsBufferSize(size int) StreamingOption {
+	return func(client *StreamingClient) {
+		client.events.bufferSize = size
+	}
+}
+
+// WithChannelBufferSize sets capacity of typed channel of events kind, e.g. of Candles for KindCandle.
+func WithChannelBufferSize(kind EventKind, size int) StreamingOption {
+	return func(client *StreamingClient) {
+		if client.events.bufferSizes == nil {
+			client.events.bufferSizes = make(map[EventKind]int)
+		}
+		client.events.bufferSizes[kind] = size
+	}
+}
+
+// WithEventsOverflowPolicy sets policy of Events, Candles and others for full channel buffer, BlockOnFull by default.
+func WithEventsOverflowPolicy(policy OverflowPolicy) StreamingOption {
+	return func(client *StreamingClient) {
+		client.events.overflow = policy
+	}
+}
+
+// Events starts read loop on first call and returns channel with decoded events, alternative
+// to callback of RunReadLoop. By default read loop blocks while channel buffer is full, so slow consumer
+// delays reading of next messages but events are not lost, see WithEventsOverflowPolicy. Channel is closed when read loop
+// is stopped, see EventsErr. Events shouldn't be used together with RunReadLoop.
+func (c *StreamingClient) Events() <-chan interface{} {
+	c.events.mu.Lock()
+	if c.events.all == nil {
+		c.events.all = make(chan interface{}, c.events.bufferSize)
+		if c.events.stopped {
+			close(c.events.all)
+		}
+	}
+	ch := c.events.all
+	c.events.mu.Unlock()
+
+	c.startEventsLoop()
+
+	return ch
+}
+
+// Candles starts read loop on first call like Events and returns channel with candle events only.
+// Channels of Candles, OrderBooks and others can be used together and with Events, each event is sent to all
+// of them by overflow policy of WithEventsOverflowPolicy, so with BlockOnFull every channel has to be read.
+// Capacity is set by WithChannelBufferSize. Channels are closed when read loop is stopped, see EventsErr.
+func (c *StreamingClient) Candles() <-chan CandleEvent {
+	return c.typedChannel(KindCandle, make(chan CandleEvent, c.events.size(KindCandle))).(chan CandleEvent)
+}
+
+// OrderBooks returns channel with orderbook events, see Candles.
+func (c *StreamingClient) OrderBooks() <-chan OrderBookEvent {
+	return c.typedChannel(KindOrderBook, make(chan OrderBookEvent, c.events.size(KindOrderBook))).(chan OrderBookEvent)
+}
+
+// InstrumentInfos returns channel with instrument info events, see Candles.
+func (c *StreamingClient) InstrumentInfos() <-chan InstrumentInfoEvent {
+	return c.typedChannel(KindInstrumentInfo, make(chan InstrumentInfoEvent, c.events.size(KindInstrumentInfo))).(chan InstrumentInfoEvent)
+}
+
+// TradingStatuses returns channel with trading status events, see Candles.
+func (c *StreamingClient) TradingStatuses() <-chan TradingStatusEvent {
+	return c.typedChannel(KindTradingStatus, make(chan TradingStatusEvent, c.events.size(KindTradingStatus))).(chan TradingStatusEvent)
+}
+
+// Errors returns channel with error events, see Candles.
+func (c *StreamingClient) Errors() <-chan ErrorEvent {
+	return c.typedChannel(KindError, make(chan ErrorEvent, c.events.size(KindError))).(chan ErrorEvent)
+}
+
+func (e *eventsChannels) size(kind EventKind) int {
+	if size, ok := e.bufferSizes[kind]; ok {
+		return size
+	}
+
+	return e.bufferSize
+}
+
+// typedChannel registers ch for kind, it returns channel registered before when there is one.
+func (c *StreamingClient) typedChannel(kind EventKind, ch interface{}) interface{} {
+	c.events.mu.Lock()
+	if c.events.typed == nil {
+		c.events.typed = make(map[EventKind]reflect.Value)
+	}
+	registered, ok := c.events.typed[kind]
+	if !ok {
+		registered = reflect.ValueOf(ch)
+		c.events.typed[kind] = registered
+		if c.events.stopped {
+			registered.Close()
+		}
+	}
+	c.events.mu.Unlock()
+
+	c.startEventsLoop()
+
+	return registered.Interface()
+}
+
+func (c *StreamingClient) startEventsLoop() {
+	c.events.once.Do(func() {
+		go func() {
+			err := c.RunReadLoop(c.sendEvent)
+
+			c.events.mu.Lock()
+			defer c.events.mu.Unlock()
+
+			c.events.err = err
+			c.events.stopped = true
+			if c.events.all != nil {
+				close(c.events.all)
+			}
+			for _, ch := range c.events.typed {
+				ch.Close()
+			}
+		}()
+	})
+}
+
+// DroppedEvents returns count of events dropped by overflow policy of Events, Candles and others.
+func (c *StreamingClient) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&c.droppedEvents)
+}
+
+// sendEvent is called by read loop only, so channels are closed after last send.
+func (c *StreamingClient) sendEvent(event interface{}) error {
+	c.events.mu.Lock()
+	all := c.events.all
+	typed, hasTyped := c.events.typed[event.(StreamEvent).Kind()]
+	c.events.mu.Unlock()
+
+	if all != nil {
+		if !c.send(reflect.ValueOf(all), reflect.ValueOf(event)) {
+			return ErrConnectionClosed
+		}
+	}
+	if hasTyped {
+		if !c.send(typed, reflect.ValueOf(event)) {
+			return ErrConnectionClosed
+		}
+	}
+
+	return nil
+}
+
+// send puts event to ch by overflow policy, it returns false when client is closed while blocked.
+func (c *StreamingClient) send(ch, event reflect.Value) bool {
+	if c.events.overflow == BlockOnFull {
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: ch, Send: event},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.done)},
+		})
+
+		return chosen == 0
+	}
+
+	if ch.TrySend(event) {
+		return true
+	}
+
+	if c.events.overflow == DropOldest {
+		if _, ok := ch.TryRecv(); ok {
+			atomic.AddUint64(&c.droppedEvents, 1)
+		}
+		if ch.TrySend(event) {
+			return true
+		}
+	}
+
+	atomic.AddUint64(&c.droppedEvents, 1)
+
+	return true
+}
+
+// EventsErr returns error stopped read loop started by Events, Candles or others.
+// It returns nil while read loop is running.
+func (c *StreamingClient) EventsErr() error {
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+
+	return c.events.err
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func sendCandles(t *testing.T, srv *sdktest.Server, closePrices ...float64) {
+	t.Helper()
+
+	for _, price := range closePrices {
+		if err := srv.Send(map[string]interface{}{
+			"event":   "candle",
+			"payload": map[string]interface{}{"figi": "FIGI", "interval": "1min", "c": price},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sendTradingStatus(t *testing.T, srv *sdktest.Server) {
+	t.Helper()
+
+	if err := srv.Send(map[string]interface{}{
+		"event":   "trading_status",
+		"payload": map[string]interface{}{"figi": "FIGI", "trade_status": "normal_trading"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTypedChannels(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	candles := client.Candles()
+	statuses := client.TradingStatuses()
+
+	sendCandles(t, srv, 1)
+	sendTradingStatus(t, srv)
+
+	select {
+	case candle := <-candles:
+		if candle.Candle.ClosePrice != 1 {
+			t.Fatalf("unexpected candle %+v", candle)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("no candle")
+	}
+	select {
+	case <-statuses:
+	case <-time.After(testTimeout):
+		t.Fatal("no trading status")
+	}
+
+	client.Close()
+	for range candles {
+	}
+	for range statuses {
+	}
+	if _, ok := <-client.OrderBooks(); ok {
+		t.Fatal("channel requested after stop is open")
+	}
+}
+
+func TestTypedChannelsOverflow(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   float64
+	}{
+		{policy: DropNewest, want: 1},
+		{policy: DropOldest, want: 3},
+	}
+
+	for _, tt := range tests {
+		srv := sdktest.NewServer()
+		client := newTestClient(t, srv, WithEventsOverflowPolicy(tt.policy), WithChannelBufferSize(KindCandle, 1))
+		candles := client.Candles()
+		statuses := client.TradingStatuses()
+
+		sendCandles(t, srv, 1, 2, 3)
+		// trading status is read after candles, so all of them are dispatched then
+		sendTradingStatus(t, srv)
+		select {
+		case <-statuses:
+		case <-time.After(testTimeout):
+			t.Fatal("no trading status")
+		}
+
+		if candle := <-candles; candle.Candle.ClosePrice != tt.want {
+			t.Fatalf("policy %d: candle with close %v, want %v", tt.policy, candle.Candle.ClosePrice, tt.want)
+		}
+		if dropped := client.DroppedEvents(); dropped != 2 {
+			t.Fatalf("policy %d: %d events dropped, want 2", tt.policy, dropped)
+		}
+
+		client.Close()
+		srv.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package sdk
+
+import "sync"
+
+// Handlers contains optional typed handlers of streaming events, see RunReadLoopHandlers.
+// Events without handler are skipped.
+type Handlers struct {
+	OnCandle         func(CandleEvent) error
+	OnOrderBook      func(OrderBookEvent) error
+	OnInstrumentInfo func(InstrumentInfoEvent) error
+	OnTradingStatus  func(TradingStatusEvent) error
+	OnError          func(ErrorEvent) error
+}
+
+// RunReadLoopHandlers registers non-nil handlers of h, see OnCandle, and runs read loop calling them.
+// Handlers registered before are kept for events without handler in h.
+func (c *StreamingClient) RunReadLoopHandlers(h Handlers) error {
+	c.typed.set(h)
+
+	return c.RunReadLoop(nil)
+}
+
+func (h Handlers) handle(event interface{}) error {
+	switch event := event.(type) {
+	case CandleEvent:
+		if h.OnCandle != nil {
+			return h.OnCandle(event)
+		}
+	case OrderBookEvent:
+		if h.OnOrderBook != nil {
+			return h.OnOrderBook(event)
+		}
+	case InstrumentInfoEvent:
+		if h.OnInstrumentInfo != nil {
+			return h.OnInstrumentInfo(event)
+		}
+	case TradingStatusEvent:
+		if h.OnTradingStatus != nil {
+			return h.OnTradingStatus(event)
+		}
+	case ErrorEvent:
+		if h.OnError != nil {
+			return h.OnError(event)
+		}
+	}
+
+	return nil
+}
+
+// handles reports whether there is handler for event name.
+func (h Handlers) handles(name string) bool {
+	switch name {
+	case "candle":
+		return h.OnCandle != nil
+	case "orderbook":
+		return h.OnOrderBook != nil
+	case "instrument_info":
+		return h.OnInstrumentInfo != nil
+	case "trading_status":
+		return h.OnTradingStatus != nil
+	case "error":
+		return h.OnError != nil
+	default:
+		return false
+	}
+}
+
+type typedHandlers struct {
+	mu       sync.RWMutex
+	handlers Handlers
+}
+
+// set replaces registered handlers by non-nil handlers of h.
+func (t *typedHandlers) set(h Handlers) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if h.OnCandle != nil {
+		t.handlers.OnCandle = h.OnCandle
+	}
+	if h.OnOrderBook != nil {
+		t.handlers.OnOrderBook = h.OnOrderBook
+	}
+	if h.OnInstrumentInfo != nil {
+		t.handlers.OnInstrumentInfo = h.OnInstrumentInfo
+	}
+	if h.OnTradingStatus != nil {
+		t.handlers.OnTradingStatus = h.OnTradingStatus
+	}
+	if h.OnError != nil {
+		t.handlers.OnError = h.OnError
+	}
+}
+
+func (t *typedHandlers) get() Handlers {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.handlers
+}
+
+// OnCandle registers handler of candle events called by read loop before RunReadLoop callback.
+// Handler error is handled by policy of WithOnCallbackError, like error of callback.
+// Callback of RunReadLoop can be nil when only registered handlers are used, then events without
+// handlers aren't decoded unless SetEventFilter is set or they are needed by subscription handles and waiters.
+func (c *StreamingClient) OnCandle(fn func(CandleEvent) error) {
+	c.typed.set(Handlers{OnCandle: fn})
+}
+
+// OnOrderBook registers handler of orderbook events, see OnCandle.
+func (c *StreamingClient) OnOrderBook(fn func(OrderBookEvent) error) {
+	c.typed.set(Handlers{OnOrderBook: fn})
+}
+
+// OnInstrumentInfo registers handler of instrument info events, see OnCandle.
+func (c *StreamingClient) OnInstrumentInfo(fn func(InstrumentInfoEvent) error) {
+	c.typed.set(Handlers{OnInstrumentInfo: fn})
+}
+
+// OnTradingStatus registers handler of trading status events, see OnCandle.
+func (c *StreamingClient) OnTradingStatus(fn func(TradingStatusEvent) error) {
+	c.typed.set(Handlers{OnTradingStatus: fn})
+}
+
+// OnError registers handler of error events, see OnCandle.
+func (c *StreamingClient) OnError(fn func(ErrorEvent) error) {
+	c.typed.set(Handlers{OnError: fn})
+}
+
+func (c *StreamingClient) dispatchTyped(event StreamEvent) error {
+	return c.typed.get().handle(event)
+}
+
+// shouldDecode reports whether event has to be decoded and whether decoded event is delivered
+// to callback and registered handlers. Events with unknown name are passed to unknown event handler.
+// Error events and events needed by subscription handles and waiters are decoded even when
+// they are rejected by event filter, filter decides only delivery of them.
+func (c *StreamingClient) shouldDecode(name string, hasCallback bool) (decode, deliver bool) {
+	deliver = c.eventFilter == nil || c.eventFilter(name)
+
+	if name == "error" || c.hasWaitersOrHandles() {
+		return true, deliver
+	}
+	if !deliver {
+		return false, false
+	}
+	if c.eventFilter != nil || hasCallback || !isKnownEventName(name) {
+		return true, true
+	}
+
+	return c.typed.get().handles(name), true
+}
+
+func (c *StreamingClient) hasWaitersOrHandles() bool {
+	c.handlesMu.RLock()
+	handles := len(c.handles)
+	c.handlesMu.RUnlock()
+
+	c.waitersMu.Lock()
+	waiters := len(c.waiters)
+	c.waitersMu.Unlock()
+
+	return handles > 0 || waiters > 0
+}
@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func TestRegisteredHandlers(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	var decodes int64
+	client := newTestClient(t, srv, WithDecoder(DecoderFunc(func(data []byte, v interface{}) error {
+		atomic.AddInt64(&decodes, 1)
+		return json.Unmarshal(data, v)
+	})))
+
+	errStop := errors.New("stop")
+	candles := make(chan CandleEvent, 1)
+	client.OnCandle(func(event CandleEvent) error {
+		candles <- event
+		return errStop
+	})
+	errc := make(chan error, 1)
+	go func() {
+		// handler of RunReadLoopHandlers is used together with registered one
+		errc <- client.RunReadLoopHandlers(Handlers{OnError: func(ErrorEvent) error { return nil }})
+	}()
+
+	if err := srv.Send(map[string]interface{}{
+		"event":   "orderbook",
+		"payload": map[string]interface{}{"figi": "FIGI", "depth": 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sendCandles(t, srv, 1)
+
+	if err := waitErr(t, errc); err != errStop {
+		t.Fatalf("handler error is not returned, got %v", err)
+	}
+	if candle := <-candles; candle.Candle.ClosePrice != 1 {
+		t.Fatalf("unexpected candle %+v", candle)
+	}
+	// event names of both messages are peeked, orderbook without handler isn't decoded
+	if n := atomic.LoadInt64(&decodes); n != 3 {
+		t.Fatalf("%d decodes, want 3", n)
+	}
+}
@@ -0,0 +1,47 @@
+package sdk
+
+// LeveledLogger is optional extension of Logger with log levels.
+// Unknown events are logged at debug level, events which can't be decoded
+// and connection problems at warn level, callback errors at error level.
+type LeveledLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+type logLevel int
+
+const (
+	logDebug logLevel = iota
+	logInfo
+	logWarn
+	logError
+)
+
+func (c *StreamingClient) logf(level logLevel, format string, args ...interface{}) {
+	if c.name != "" {
+		format = "[" + c.name + "] " + format
+	}
+
+	leveled, ok := c.logger.(LeveledLogger)
+	if !ok {
+		c.logger.Printf(format, args...)
+		return
+	}
+
+	switch level {
+	case logDebug:
+		leveled.Debugf(format, args...)
+	case logInfo:
+		leveled.Infof(format, args...)
+	case logWarn:
+		leveled.Warnf(format, args...)
+	default:
+		leveled.Errorf(format, args...)
+	}
+}
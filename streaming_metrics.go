@@ -0,0 +1,28 @@
+package sdk
+
+// Metrics receives counters of streaming client, e.g. to export them to Prometheus.
+// Methods are called from read loop, so they have to be fast.
+type Metrics interface {
+	// EventReceived is called for each message with event name, empty for undecodable messages.
+	EventReceived(name string)
+	// DecodeError is called when message of known event can't be decoded.
+	DecodeError(name string)
+	// Reconnect is called when lost connection is being reconnected.
+	Reconnect()
+	// PongTimeout is called when connection is lost because pong isn't received in time.
+	PongTimeout()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) EventReceived(string) {}
+func (noopMetrics) DecodeError(string)   {}
+func (noopMetrics) Reconnect()           {}
+func (noopMetrics) PongTimeout()         {}
+
+// WithMetrics sets metrics of streaming client, metrics are not collected by default.
+func WithMetrics(metrics Metrics) StreamingOption {
+	return func(client *StreamingClient) {
+		client.metrics = metrics
+	}
+}
@@ -0,0 +1,301 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// ReconnectConfig configures automatic reconnect of streaming client.
+// When connection is lost, RunReadLoop connects again and resubscribes to all
+// active subscriptions, error is returned only when all retries are failed.
+type ReconnectConfig struct {
+	// MaxRetries is count of connect attempts after connection is lost.
+	MaxRetries int
+	// Backoff is delay before each connect attempt when InitialBackoff is not set.
+	Backoff time.Duration
+	// InitialBackoff is delay before first connect attempt, it is multiplied by Multiplier
+	// for each next attempt and is reset when connection stays up for StableAfter.
+	InitialBackoff time.Duration
+	// MaxBackoff limits delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier of delay, 2 by default.
+	Multiplier float64
+	// StableAfter is uptime after which connection is stable and backoff is reset, DefaultStableAfter by default.
+	// Delay keeps growing across reconnects of connection which is lost earlier.
+	StableAfter time.Duration
+	// OnReconnectAttempt is called before each connect attempt with its delay.
+	OnReconnectAttempt func(attempt int, delay time.Duration)
+	// RetryInitialConnect enables the same retries for failed connect in constructor.
+	RetryInitialConnect bool
+}
+
+// DefaultStableAfter is default ReconnectConfig.StableAfter.
+const DefaultStableAfter = time.Minute
+
+func (cfg ReconnectConfig) stableAfter() time.Duration {
+	if cfg.StableAfter <= 0 {
+		return DefaultStableAfter
+	}
+
+	return cfg.StableAfter
+}
+
+// delay returns delay before attempt, exponential delay has jitter in [delay/2, delay].
+func (cfg ReconnectConfig) delay(attempt int) time.Duration {
+	if cfg.InitialBackoff <= 0 {
+		return cfg.Backoff
+	}
+
+	multiplier := cfg.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	delay := float64(cfg.InitialBackoff)
+	for i := 1; i < attempt && (cfg.MaxBackoff <= 0 || delay < float64(cfg.MaxBackoff)); i++ {
+		delay *= multiplier
+	}
+	if cfg.MaxBackoff > 0 && delay > float64(cfg.MaxBackoff) {
+		delay = float64(cfg.MaxBackoff)
+	}
+
+	half := int64(delay / 2)
+
+	return time.Duration(half + rand.Int63n(int64(delay)-half+1))
+}
+
+// WithReconnect enables automatic reconnect with resubscription.
+func WithReconnect(cfg ReconnectConfig) StreamingOption {
+	return func(client *StreamingClient) {
+		client.reconnectCfg = &cfg
+	}
+}
+
+func (c *StreamingClient) getConn() *websocket.Conn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
+	return c.conn
+}
+
+func (c *StreamingClient) setConn(conn *websocket.Conn) {
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+
+	atomic.StoreInt64(&c.connectedAt, time.Now().UnixNano())
+}
+
+func (c *StreamingClient) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectInitial connects in constructor, it retries by reconnect config when RetryInitialConnect is set.
+func (c *StreamingClient) connectInitial(ctx context.Context) (*websocket.Conn, error) {
+	conn, err := c.connect(ctx)
+	if err == nil || c.reconnectCfg == nil || !c.reconnectCfg.RetryInitialConnect {
+		return conn, err
+	}
+
+	for attempt := 1; attempt <= c.reconnectCfg.MaxRetries; attempt++ {
+		if err == ErrForbidden || err == ErrUnauthorized {
+			return nil, err
+		}
+
+		delay := c.reconnectCfg.delay(attempt)
+		if c.reconnectCfg.OnReconnectAttempt != nil {
+			c.reconnectCfg.OnReconnectAttempt(attempt, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		conn, err = c.connect(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		c.logf(logWarn, "Can't connect, attempt %d: %v", attempt, err)
+	}
+
+	return nil, errors.Wrapf(err, "can't connect after %d attempts", c.reconnectCfg.MaxRetries)
+}
+
+// resetBackoffIfStable resets attempts counter of backoff when lost connection was stable.
+func (c *StreamingClient) resetBackoffIfStable() {
+	connectedAt := time.Unix(0, atomic.LoadInt64(&c.connectedAt))
+	if time.Since(connectedAt) >= c.reconnectCfg.stableAfter() {
+		c.backoffAttempts = 0
+	}
+}
+
+// backoffDelay returns delay before next connect attempt, attempts are counted across reconnects.
+func (c *StreamingClient) backoffDelay() time.Duration {
+	c.backoffAttempts++
+
+	return c.reconnectCfg.delay(c.backoffAttempts)
+}
+
+func (c *StreamingClient) reconnect() error {
+	c.resetBackoffIfStable()
+	c.getConn().Close()
+
+	err := ErrConnectionClosed
+	for attempt := 1; attempt <= c.reconnectCfg.MaxRetries; attempt++ {
+		delay := c.backoffDelay()
+		if c.reconnectCfg.OnReconnectAttempt != nil {
+			c.reconnectCfg.OnReconnectAttempt(attempt, delay)
+		}
+
+		select {
+		case <-c.done:
+			return ErrConnectionClosed
+		case <-time.After(delay):
+		}
+
+		c.stopPing()
+
+		var conn *websocket.Conn
+		conn, err = c.connect(context.Background())
+		if err == ErrForbidden || err == ErrUnauthorized {
+			return err
+		}
+		if err != nil {
+			c.logf(logWarn, "Can't reconnect, attempt %d: %v", attempt, err)
+			continue
+		}
+		// SetToken can't replace connection concurrently, so no connection is leaked
+		c.swapMu.Lock()
+		c.replaceConn(conn)
+		c.swapMu.Unlock()
+		if c.isClosed() {
+			// Close is called while connecting, it could miss new connection
+			conn.Close()
+			return ErrConnectionClosed
+		}
+
+		if err = c.resubscribe(); err != nil {
+			c.logf(logWarn, "Can't resubscribe, attempt %d: %v", attempt, err)
+			conn.Close()
+			continue
+		}
+
+		// hooks are called in Connected state, so they can subscribe, Closed state is kept
+		atomic.CompareAndSwapInt32(&c.state, int32(Reconnecting), int32(Connected))
+		if c.onConnect != nil {
+			c.onConnect()
+		}
+		if c.onReconnect != nil {
+			c.onReconnect(attempt)
+		}
+
+		return nil
+	}
+
+	return errors.Wrapf(err, "can't reconnect after %d attempts", c.reconnectCfg.MaxRetries)
+}
+
+// SetToken connects with new token, resubscribes to active subscriptions and closes previous connection.
+// When new connection can't be established, e.g. ErrForbidden, previous token and connection are kept.
+// It returns ErrReconnecting while client reconnects, reconnect uses current token anyway,
+// and ErrConnectionClosed after Close or stop of read loop.
+func (c *StreamingClient) SetToken(token string) error {
+	c.swapMu.Lock()
+	defer c.swapMu.Unlock()
+
+	if c.isClosed() || c.State() == Closed {
+		return ErrConnectionClosed
+	}
+	if c.State() == Reconnecting {
+		return ErrReconnecting
+	}
+
+	c.tokenMu.Lock()
+	previous := c.token
+	c.token = token
+	c.tokenMu.Unlock()
+
+	conn, err := c.connect(context.Background())
+	if err != nil {
+		c.tokenMu.Lock()
+		c.token = previous
+		c.tokenMu.Unlock()
+
+		return err
+	}
+	// Close doesn't wait for dial, so connection is dropped when client is closed meanwhile
+	if c.isClosed() {
+		c.stopPing()
+		conn.Close()
+
+		c.tokenMu.Lock()
+		c.token = previous
+		c.tokenMu.Unlock()
+
+		return ErrConnectionClosed
+	}
+
+	c.replaceConn(conn)
+
+	if err := c.resubscribe(); err != nil {
+		return err
+	}
+	if c.onConnect != nil {
+		c.onConnect()
+	}
+
+	return nil
+}
+
+// replaceConn sets new connection and closes previous one, it should be called with swapMu held.
+func (c *StreamingClient) replaceConn(conn *websocket.Conn) {
+	old := c.getConn()
+	c.setConn(conn)
+	old.Close()
+}
+
+// RotateToken sets token of both clients, any of them can be nil. Streaming client is updated first,
+// so when new token is rejected, e.g. ErrForbidden, both clients keep previous token.
+func RotateToken(token string, rest *RestClient, streaming *StreamingClient) error {
+	if streaming != nil {
+		if err := streaming.SetToken(token); err != nil {
+			return err
+		}
+	}
+	if rest != nil {
+		rest.SetToken(token)
+	}
+
+	return nil
+}
+
+// resubscribe replays active subscriptions holding write lock, so subscribes can't interleave with replay.
+func (c *StreamingClient) resubscribe() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	for _, params := range c.activeSubscriptions() {
+		msg, err := json.Marshal(params.request("subscribe"))
+		if err != nil {
+			return errors.Wrap(err, "can't marshal message")
+		}
+		if err := c.writeTextLocked(msg); err != nil {
+			return errors.Wrap(err, "can't subscribe to event")
+		}
+	}
+
+	return nil
+}
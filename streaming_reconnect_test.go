@@ -0,0 +1,224 @@
+package sdk
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func testReconnect() StreamingOption {
+	return WithReconnect(ReconnectConfig{MaxRetries: 5, Backoff: 10 * time.Millisecond})
+}
+
+func TestReconnectHooksCanSubscribe(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	var client *StreamingClient
+	errc := make(chan error, 2)
+	client = newTestClient(t, srv,
+		testReconnect(),
+		WithOnReconnect(func(int) {
+			errc <- client.SubscribeInstrumentInfo("FIGI", "")
+		}),
+	)
+	runReadLoop(client)
+
+	srv.DropConnections()
+	if err := waitErr(t, errc); err != nil {
+		t.Fatalf("subscribe from hook: %v", err)
+	}
+	if req := nextRequest(t, srv); req.Event != "instrument_info:subscribe" || req.FIGI != "FIGI" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if state := client.State(); state != Connected {
+		t.Fatalf("state %s", state)
+	}
+}
+
+func TestBackoffIsResetForStableConnection(t *testing.T) {
+	cfg := ReconnectConfig{InitialBackoff: 10 * time.Millisecond, Multiplier: 2, StableAfter: time.Minute}
+	client := &StreamingClient{reconnectCfg: &cfg}
+
+	// connection is lost right after connect, so delay keeps growing across reconnects
+	atomic.StoreInt64(&client.connectedAt, time.Now().UnixNano())
+	client.resetBackoffIfStable()
+	client.backoffDelay()
+	client.backoffDelay()
+	client.resetBackoffIfStable()
+	if delay := client.backoffDelay(); delay < 20*time.Millisecond || delay > 40*time.Millisecond {
+		t.Fatalf("delay of flapping connection %s, want [20ms, 40ms]", delay)
+	}
+
+	atomic.StoreInt64(&client.connectedAt, time.Now().Add(-time.Hour).UnixNano())
+	client.resetBackoffIfStable()
+	if delay := client.backoffDelay(); delay < 5*time.Millisecond || delay > 10*time.Millisecond {
+		t.Fatalf("delay of stable connection %s, want [5ms, 10ms]", delay)
+	}
+}
+
+func TestRotateToken(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	streaming := newTestClient(t, srv)
+	rest := NewRestClient("token")
+	runReadLoop(streaming)
+
+	if err := streaming.SubscribeInstrumentInfo("FIGI", ""); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	nextRequest(t, srv)
+
+	if err := RotateToken("rotated", rest, streaming); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if req := nextRequest(t, srv); req.Event != "instrument_info:subscribe" || req.FIGI != "FIGI" {
+		t.Fatalf("subscription isn't replayed, got %+v", req)
+	}
+	headers := srv.Headers()
+	if got := headers[len(headers)-1].Get("Authorization"); got != "Bearer rotated" {
+		t.Fatalf("authorization %q", got)
+	}
+	if got := rest.getToken(); got != "rotated" {
+		t.Fatalf("rest token %q", got)
+	}
+
+	srv.RejectWith(http.StatusForbidden)
+	if err := RotateToken("rejected", rest, streaming); err != ErrForbidden {
+		t.Fatalf("rotate with rejected token: %v", err)
+	}
+	if got := rest.getToken(); got != "rotated" {
+		t.Fatalf("rest token %q is changed by rejected rotation", got)
+	}
+
+	streaming.setState(Reconnecting)
+	if err := streaming.SetToken("token"); err != ErrReconnecting {
+		t.Fatalf("set token while reconnecting: %v", err)
+	}
+}
+
+func TestReconnectResubscribes(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	reconnected := make(chan struct{}, 1)
+	client := newTestClient(t, srv, testReconnect(), WithOnReconnect(func(int) { reconnected <- struct{}{} }))
+	runReadLoop(client)
+
+	if err := client.SubscribeCandle("A", CandleInterval1Min, ""); err != nil {
+		t.Fatal(err)
+	}
+	// duplicate subscription is tracked once
+	if err := client.SubscribeCandle("A", CandleInterval1Min, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeOrderbook("A", 10, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeInstrumentInfo("B", ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		nextRequest(t, srv)
+	}
+	noRequest(t, srv)
+
+	srv.DropConnections()
+	select {
+	case <-reconnected:
+	case <-time.After(testTimeout):
+		t.Fatal("no reconnect")
+	}
+
+	got := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		req := nextRequest(t, srv)
+		got[req.Event+" "+req.FIGI]++
+	}
+	noRequest(t, srv)
+	for _, want := range []string{"candle:subscribe A", "orderbook:subscribe A", "instrument_info:subscribe B"} {
+		if got[want] != 1 {
+			t.Fatalf("replayed requests %v, want one %s", got, want)
+		}
+	}
+}
+
+func TestReconnectContinuesReadLoop(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, testReconnect())
+	events := make(chan StreamEvent, 10)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.RunReadLoop(func(event interface{}) error {
+			events <- event.(StreamEvent)
+			return nil
+		})
+	}()
+
+	sendCandles(t, srv, 1)
+	nextEvent(t, events)
+
+	srv.DropConnections()
+	waitConnections(t, srv, 1)
+	sendCandles(t, srv, 2)
+	if event, ok := nextEvent(t, events).(CandleEvent); !ok || event.Candle.ClosePrice != 2 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("read loop is stopped by reconnect: %v", err)
+	default:
+	}
+}
+
+func TestPingAfterReconnect(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, testReconnect(), WithPingPong(time.Second, 10*time.Millisecond))
+	runReadLoop(client)
+
+	// every pong updates round trip time, so it keeps changing while pings are sent periodically
+	waitRTTChange(t, client)
+	waitRTTChange(t, client)
+
+	srv.DropConnections()
+	waitConnections(t, srv, 1)
+	waitRTTChange(t, client)
+	waitRTTChange(t, client)
+}
+
+func waitRTTChange(t *testing.T, client *StreamingClient) {
+	t.Helper()
+
+	rtt := client.LastRTT()
+	deadline := time.Now().Add(testTimeout)
+	for client.LastRTT() == rtt {
+		if time.Now().After(deadline) {
+			t.Fatal("no pong received")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetTokenAfterClose(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, WithPingPong(time.Second, 10*time.Millisecond))
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetToken("rotated"); err != ErrConnectionClosed {
+		t.Fatalf("set token after close: %v", err)
+	}
+	if n := len(srv.Headers()); n != 1 {
+		t.Fatalf("%d connections, want 1", n)
+	}
+}
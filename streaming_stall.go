@@ -0,0 +1,52 @@
+package sdk
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// minStallCheckInterval limits how often stall is checked, so small timeout doesn't spin.
+const minStallCheckInterval = 10 * time.Millisecond
+
+// WithStallTimeout enables stall detection: when there are active subscriptions and no messages
+// are received for timeout, connection is closed, so read loop reconnects when WithReconnect is set.
+// Ping/pong frames don't count as messages. Stall detection is disabled by default.
+//
+// Streaming api doesn't send trading schedule, so stall is detected out of trading hours too:
+// instruments without trades don't send candles. Use timeout longer than pauses of subscribed instruments.
+func WithStallTimeout(timeout time.Duration) StreamingOption {
+	return func(client *StreamingClient) {
+		client.stallTimeout = timeout
+	}
+}
+
+func (c *StreamingClient) runStallCheck() {
+	interval := c.stallTimeout / 2
+	if interval < minStallCheckInterval {
+		interval = minStallCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if c.State() != Connected || len(c.activeSubscriptions()) == 0 {
+				continue
+			}
+
+			// idle time is counted from connect too, so new connection isn't closed at once
+			last := atomic.LoadInt64(&c.lastMessageAt)
+			if connectedAt := atomic.LoadInt64(&c.connectedAt); connectedAt > last {
+				last = connectedAt
+			}
+			if idle := time.Since(time.Unix(0, last)); idle > c.stallTimeout {
+				c.logf(logWarn, "No messages for %s, closing stalled connection", idle)
+				c.getConn().Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func TestStallTimeout(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv, WithStallTimeout(time.Nanosecond))
+	errc := runReadLoop(client)
+
+	// idle client without subscriptions isn't stalled
+	time.Sleep(5 * minStallCheckInterval)
+	if state := client.State(); state != Connected {
+		t.Fatalf("state %s", state)
+	}
+
+	if err := client.SubscribeInstrumentInfo("FIGI", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitErr(t, errc); !errors.Is(err, ErrConnectionClosed) {
+		t.Fatalf("read loop of stalled connection: %v", err)
+	}
+}
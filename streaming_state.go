@@ -0,0 +1,86 @@
+package sdk
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// ConnState is state of streaming client connection.
+type ConnState int32
+
+const (
+	Connecting ConnState = iota
+	Connected
+	Reconnecting
+	Closed
+)
+
+// String returns name of state.
+func (s ConnState) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns current state of connection.
+func (c *StreamingClient) State() ConnState {
+	return ConnState(atomic.LoadInt32(&c.state))
+}
+
+// LastError returns last connection error, it is nil when there were no errors.
+func (c *StreamingClient) LastError() error {
+	c.lastErrMu.Lock()
+	defer c.lastErrMu.Unlock()
+
+	return c.lastErr
+}
+
+// LastMessageTime returns time of last message read by read loop, zero time when there were no messages.
+func (c *StreamingClient) LastMessageTime() time.Time {
+	at := atomic.LoadInt64(&c.lastMessageAt)
+	if at == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, at)
+}
+
+// IdleFor returns time since last message, zero when there were no messages.
+func (c *StreamingClient) IdleFor() time.Duration {
+	at := c.LastMessageTime()
+	if at.IsZero() {
+		return 0
+	}
+
+	return time.Since(at)
+}
+
+// RemoteAddr returns address of server of current connection.
+func (c *StreamingClient) RemoteAddr() net.Addr {
+	return c.getConn().RemoteAddr()
+}
+
+// LastRTT returns round trip time of last ping, zero when ping/pong is disabled or no pong is received yet.
+func (c *StreamingClient) LastRTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.lastRTT))
+}
+
+func (c *StreamingClient) setState(state ConnState) {
+	atomic.StoreInt32(&c.state, int32(state))
+}
+
+func (c *StreamingClient) setLastError(err error) {
+	c.lastErrMu.Lock()
+	c.lastErr = err
+	c.lastErrMu.Unlock()
+}
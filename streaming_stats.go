@@ -0,0 +1,49 @@
+package sdk
+
+import "time"
+
+// ConnectOutcome is result of websocket handshake.
+type ConnectOutcome string
+
+const (
+	ConnectSuccess      ConnectOutcome = "success"
+	ConnectForbidden    ConnectOutcome = "forbidden"
+	ConnectUnauthorized ConnectOutcome = "unauthorized"
+	ConnectOther        ConnectOutcome = "other"
+)
+
+// StreamingStats contains statistics of streaming client connections.
+type StreamingStats struct {
+	// Connects contains count of handshakes by outcome.
+	Connects map[ConnectOutcome]int
+	// LastConnectDuration is duration from dial start to the end of last handshake.
+	LastConnectDuration time.Duration
+	// LastConnectOutcome is outcome of last handshake.
+	LastConnectOutcome ConnectOutcome
+}
+
+// Stats returns snapshot of streaming client statistics.
+func (c *StreamingClient) Stats() StreamingStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	stats := c.stats
+	stats.Connects = make(map[ConnectOutcome]int, len(c.stats.Connects))
+	for outcome, count := range c.stats.Connects {
+		stats.Connects[outcome] = count
+	}
+
+	return stats
+}
+
+func (c *StreamingClient) observeConnect(start time.Time, outcome ConnectOutcome) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	if c.stats.Connects == nil {
+		c.stats.Connects = make(map[ConnectOutcome]int)
+	}
+	c.stats.Connects[outcome]++
+	c.stats.LastConnectDuration = time.Since(start)
+	c.stats.LastConnectOutcome = outcome
+}
@@ -0,0 +1,305 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SubscriptionBufferSize is capacity of Subscription events channel.
+const SubscriptionBufferSize = 100
+
+// ErrSubscriptionKind returned when subscription kind is not supported.
+var ErrSubscriptionKind = errors.New("invalid subscription kind")
+
+// SubscriptionParams describes single subscription of streaming api.
+// Interval is used only for candle subscriptions, Depth only for orderbook ones.
+type SubscriptionParams struct {
+	Kind      EventKind
+	FIGI      string
+	Interval  CandleInterval
+	Depth     int
+	RequestID string
+}
+
+type subscriptionKey struct {
+	kind     EventKind
+	figi     string
+	interval CandleInterval
+	depth    int
+}
+
+func (p SubscriptionParams) key() subscriptionKey {
+	return subscriptionKey{kind: p.Kind, figi: p.FIGI, interval: p.Interval, depth: p.Depth}
+}
+
+func (p SubscriptionParams) request(action string) subscribeRequest {
+	return subscribeRequest{
+		Event:     p.Kind.String() + ":" + action,
+		RequestID: p.RequestID,
+		FIGI:      p.FIGI,
+		Interval:  p.Interval,
+		Depth:     p.Depth,
+	}
+}
+
+func (p SubscriptionParams) matches(event StreamEvent) bool {
+	switch e := event.(type) {
+	case CandleEvent:
+		return p.Kind == KindCandle && p.FIGI == e.Candle.FIGI && p.Interval == e.Candle.Interval
+	case OrderBookEvent:
+		return p.Kind == KindOrderBook && p.FIGI == e.OrderBook.FIGI && p.Depth == e.OrderBook.Depth
+	case InstrumentInfoEvent:
+		return p.Kind == KindInstrumentInfo && p.FIGI == e.Info.FIGI
+	case TradingStatusEvent:
+		return p.Kind == KindInstrumentInfo && p.FIGI == e.Status.FIGI
+	case ErrorEvent:
+		return p.RequestID != "" && p.RequestID == e.Error.RequestID
+	default:
+		return false
+	}
+}
+
+// Subscription is handle of single subscription created by StreamingClient.Subscribe.
+type Subscription struct {
+	params SubscriptionParams
+	client *StreamingClient
+	events chan StreamEvent
+
+	detachOnce sync.Once
+	once       sync.Once
+	err        error
+}
+
+// Params returns parameters of subscription.
+func (s *Subscription) Params() SubscriptionParams {
+	return s.params
+}
+
+// Events returns channel with events of subscription only, including error events with
+// subscription request id. Events are delivered while RunReadLoop is running and are
+// dropped when channel buffer is full. Channel is closed after Unsubscribe.
+func (s *Subscription) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Unsubscribe cancels subscription. Handles of the same subscription share single server
+// subscription, unsubscribe is sent when the last of them is unsubscribed. It is safe to call
+// Unsubscribe several times, subsequent calls return result of the first one.
+func (s *Subscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.err = s.client.release(s)
+	})
+
+	return s.err
+}
+
+func (s *Subscription) detach() {
+	s.detachOnce.Do(func() {
+		s.client.handlesMu.Lock()
+		delete(s.client.handles, s)
+		close(s.events)
+		s.client.handlesMu.Unlock()
+	})
+}
+
+// SetSubscriptionEndedHandler sets handler called when server ends subscription.
+// Server reports it by error event with request_id of subscription, e.g. for unknown figi.
+// Such subscription is removed from tracked ones and its events channel is closed.
+// Should be called before RunReadLoop.
+func (c *StreamingClient) SetSubscriptionEndedHandler(handler func(params SubscriptionParams, event ErrorEvent)) {
+	c.subscriptionEndedHandler = handler
+}
+
+// Subscribe subscribes to events described by params and returns subscription handle.
+// Empty request id is replaced by generated one, it is available by Params. When subscription
+// is already active, handle gets request id of active subscription, so it receives its error events.
+func (c *StreamingClient) Subscribe(params SubscriptionParams) (*Subscription, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	// handles are added and released under write lock, so last handle can't be released
+	// between subscribe of new handle and its registration
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	requestID, err := c.subscribeLocked(params)
+	if err != nil {
+		return nil, err
+	}
+	params.RequestID = requestID
+
+	sub := &Subscription{
+		params: params,
+		client: c,
+		events: make(chan StreamEvent, SubscriptionBufferSize),
+	}
+
+	c.handlesMu.Lock()
+	c.handles[sub] = struct{}{}
+	c.handlesMu.Unlock()
+
+	return sub, nil
+}
+
+// release detaches handle and unsubscribes when there are no other handles of its subscription.
+func (c *StreamingClient) release(sub *Subscription) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	sub.detach()
+	if c.hasHandles(sub.params.key()) {
+		return nil
+	}
+
+	return c.unsubscribeLocked(sub.params)
+}
+
+func (c *StreamingClient) hasHandles(key subscriptionKey) bool {
+	c.handlesMu.RLock()
+	defer c.handlesMu.RUnlock()
+
+	for sub := range c.handles {
+		if sub.params.key() == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p SubscriptionParams) validate() error {
+	switch p.Kind {
+	case KindCandle:
+		if !IsValidCandleInterval(p.Interval) {
+			return ErrInterval
+		}
+	case KindOrderBook:
+		if p.Depth < 1 || p.Depth > MaxOrderbookDepth {
+			return ErrDepth
+		}
+	case KindInstrumentInfo:
+	default:
+		return ErrSubscriptionKind
+	}
+
+	return nil
+}
+
+func (c *StreamingClient) dispatchSubscriptions(event StreamEvent) {
+	var ended []*Subscription
+
+	c.handlesMu.RLock()
+	for sub := range c.handles {
+		if !sub.params.matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			c.logf(logWarn, "Subscription buffer is full, drop event %s", sub.params.FIGI)
+		}
+
+		if event.Kind() == KindError {
+			ended = append(ended, sub)
+		}
+	}
+	c.handlesMu.RUnlock()
+
+	if errorEvent, ok := event.(ErrorEvent); ok {
+		c.untrackRequest(errorEvent.Error.RequestID)
+		c.endSubscriptions(ended, errorEvent)
+	}
+}
+
+func (c *StreamingClient) endSubscriptions(ended []*Subscription, event ErrorEvent) {
+	for _, sub := range ended {
+		sub.detach()
+		c.untrack(sub.params)
+
+		if c.subscriptionEndedHandler != nil {
+			c.subscriptionEndedHandler(sub.params, event)
+		}
+	}
+}
+
+func (c *StreamingClient) track(params SubscriptionParams) {
+	c.subsMu.Lock()
+	c.subs[params.key()] = params
+	c.subsMu.Unlock()
+}
+
+func (c *StreamingClient) untrack(params SubscriptionParams) {
+	c.subsMu.Lock()
+	delete(c.subs, params.key())
+	c.subsMu.Unlock()
+}
+
+func (c *StreamingClient) tracked(params SubscriptionParams) (SubscriptionParams, bool) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	active, ok := c.subs[params.key()]
+
+	return active, ok
+}
+
+// untrackRequest removes subscriptions with requestID, server sends error event
+// with request id of subscription when subscription is failed or ended. Error event has
+// no other fields of subscription, so batch subscribes use unique request id per subscription.
+func (c *StreamingClient) untrackRequest(requestID string) {
+	if requestID == "" {
+		return
+	}
+
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for key, params := range c.subs {
+		if params.RequestID == requestID {
+			delete(c.subs, key)
+		}
+	}
+}
+
+// ActiveSubscriptions returns snapshot of active subscriptions sorted by kind and figi,
+// including ones subscribed by SubscribeCandle and others. Snapshot isn't changed by next
+// subscribes and unsubscribes, use Subscribe for handles receiving events.
+func (c *StreamingClient) ActiveSubscriptions() []SubscriptionParams {
+	return c.activeSubscriptions()
+}
+
+// ListSubscriptions returns params of active subscriptions, see ActiveSubscriptions.
+func (c *StreamingClient) ListSubscriptions() []SubscriptionParams {
+	return c.activeSubscriptions()
+}
+
+// activeSubscriptions returns params of active subscriptions sorted by kind and figi.
+func (c *StreamingClient) activeSubscriptions() []SubscriptionParams {
+	c.subsMu.Lock()
+	subs := make([]SubscriptionParams, 0, len(c.subs))
+	for _, params := range c.subs {
+		subs = append(subs, params)
+	}
+	c.subsMu.Unlock()
+
+	sort.Slice(subs, func(i, j int) bool {
+		a, b := subs[i], subs[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.FIGI != b.FIGI {
+			return a.FIGI < b.FIGI
+		}
+		if a.Interval != b.Interval {
+			return a.Interval < b.Interval
+		}
+
+		return a.Depth < b.Depth
+	})
+
+	return subs
+}
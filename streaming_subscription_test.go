@@ -0,0 +1,209 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tinkoff/invest-openapi-go-sdk/sdktest"
+)
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	runReadLoop(client)
+
+	params := SubscriptionParams{Kind: KindCandle, FIGI: "FIGI", Interval: CandleInterval1Min}
+	first, err := client.Subscribe(params)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	req := nextRequest(t, srv)
+	if req.Event != "candle:subscribe" || req.RequestID != first.Params().RequestID {
+		t.Fatalf("unexpected request %+v", req)
+	}
+
+	second, err := client.Subscribe(params)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	noRequest(t, srv)
+	if second.Params().RequestID != first.Params().RequestID {
+		t.Fatalf("request id %q of second handle, want %q", second.Params().RequestID, first.Params().RequestID)
+	}
+
+	if err := srv.Send(map[string]interface{}{
+		"event":   "candle",
+		"payload": map[string]interface{}{"figi": "FIGI", "interval": "1min", "c": 1.5},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []*Subscription{first, second} {
+		if event := nextEvent(t, sub.Events()); event.Kind() != KindCandle {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+
+	if err := first.Unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	noRequest(t, srv)
+	if _, ok := <-first.Events(); ok {
+		t.Fatal("events channel of unsubscribed handle is open")
+	}
+	if len(client.activeSubscriptions()) != 1 {
+		t.Fatal("subscription is removed while it has handles")
+	}
+
+	if err := second.Unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if req := nextRequest(t, srv); req.Event != "candle:unsubscribe" || req.FIGI != "FIGI" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if err := second.Unsubscribe(); err != nil {
+		t.Fatalf("second unsubscribe: %v", err)
+	}
+	noRequest(t, srv)
+	if len(client.activeSubscriptions()) != 0 {
+		t.Fatal("subscription is active after unsubscribe")
+	}
+}
+
+func TestBatchErrorEndsSingleSubscription(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	events := make(chan interface{}, 10)
+	go client.RunReadLoop(func(event interface{}) error {
+		events <- event
+		return nil
+	})
+
+	if err := client.SubscribeOrderbooks([]string{"A", "B", "C"}, 10, "batch"); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	for i, want := range []string{"batch-0", "batch-1", "batch-2"} {
+		if req := nextRequest(t, srv); req.RequestID != want {
+			t.Fatalf("request %d has id %q, want %q", i, req.RequestID, want)
+		}
+	}
+
+	if err := srv.Send(map[string]interface{}{
+		"event":   "error",
+		"payload": map[string]interface{}{"request_id": "batch-1", "error": "unknown figi"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-events:
+	case <-time.After(testTimeout):
+		t.Fatal("no error event")
+	}
+
+	active := client.ActiveSubscriptions()
+	if len(active) != 2 || active[0].FIGI != "A" || active[1].FIGI != "C" {
+		t.Fatalf("unexpected active subscriptions %+v", active)
+	}
+	if active[0].Kind != KindOrderBook || active[0].Depth != 10 || active[0].RequestID != "batch-0" {
+		t.Fatalf("unexpected snapshot %+v", active[0])
+	}
+
+	if err := client.UnsubscribeOrderbook(active[0].FIGI, active[0].Depth, ""); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if req := nextRequest(t, srv); req.Event != "orderbook:unsubscribe" || req.FIGI != "A" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if active := client.ActiveSubscriptions(); len(active) != 1 || active[0].FIGI != "C" {
+		t.Fatalf("unexpected active subscriptions %+v", active)
+	}
+}
+
+func TestSubscriptionEndedByServer(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	ended := make(chan SubscriptionParams, 1)
+	client.SetSubscriptionEndedHandler(func(params SubscriptionParams, event ErrorEvent) {
+		if event.Error.Error != "instrument is delisted" {
+			t.Errorf("unexpected error event %+v", event)
+		}
+		ended <- params
+	})
+	runReadLoop(client)
+
+	sub, err := client.Subscribe(SubscriptionParams{Kind: KindOrderBook, FIGI: "A", Depth: 10, RequestID: "handle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeInstrumentInfo("B", "plain"); err != nil {
+		t.Fatal(err)
+	}
+	nextRequest(t, srv)
+	nextRequest(t, srv)
+
+	for _, requestID := range []string{"handle", "plain"} {
+		if err := srv.Send(map[string]interface{}{
+			"event":   "error",
+			"payload": map[string]interface{}{"request_id": requestID, "error": "instrument is delisted"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case params := <-ended:
+		if params.FIGI != "A" || params.Kind != KindOrderBook {
+			t.Fatalf("ended subscription %+v", params)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("ended handler isn't called")
+	}
+	if event := nextEvent(t, sub.Events()); event.Kind() != KindError {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("events channel of ended subscription is open")
+	}
+
+	deadline := time.Now().Add(testTimeout)
+	for len(client.activeSubscriptions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ended subscriptions are tracked: %+v", client.activeSubscriptions())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListSubscriptions(t *testing.T) {
+	srv := sdktest.NewServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	if err := client.SubscribeOrderbook("B", 10, "book"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SubscribeCandle("A", CandleInterval1Min, "candle"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SubscriptionParams{
+		{Kind: KindCandle, FIGI: "A", Interval: CandleInterval1Min, RequestID: "candle"},
+		{Kind: KindOrderBook, FIGI: "B", Depth: 10, RequestID: "book"},
+	}
+	if got := client.ListSubscriptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscriptions %+v, want %+v", got, want)
+	}
+
+	if err := client.UnsubscribeAll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.ListSubscriptions(); len(got) != 0 {
+		t.Fatalf("subscriptions %+v after unsubscribe", got)
+	}
+}